@@ -20,6 +20,7 @@ import (
 	"reflect"
 	"strconv"
 
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/anypb"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
@@ -30,8 +31,20 @@ import (
 
 const (
 	metadataKeyTTLInSeconds = "ttlInSeconds"
+	actorReentrancyIDKey    = "dapr-reentrancy-id"
 )
 
+type actorReentrancyCtxKey struct{}
+
+// WithActorReentrancy returns a copy of ctx carrying the given actor reentrancy ID.
+// Actor invocations made with the returned context send the ID to the runtime so that
+// a call back into an actor already in the same call chain is allowed instead of blocking.
+// This requires reentrancy to be enabled in the actor configuration for the actor type,
+// see https://docs.dapr.io/developing-applications/building-blocks/actors/actor-reentrancy/.
+func WithActorReentrancy(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, actorReentrancyCtxKey{}, id)
+}
+
 type InvokeActorRequest struct {
 	ActorType string
 	ActorID   string
@@ -59,6 +72,10 @@ func (c *GRPCClient) InvokeActor(ctx context.Context, in *InvokeActorRequest) (o
 		return nil, errors.New("actor invocation actorID required")
 	}
 
+	if id, ok := ctx.Value(actorReentrancyCtxKey{}).(string); ok && id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, actorReentrancyIDKey, id)
+	}
+
 	req := &pb.InvokeActorRequest{
 		ActorType: in.ActorType,
 		ActorId:   in.ActorID,
@@ -66,7 +83,7 @@ func (c *GRPCClient) InvokeActor(ctx context.Context, in *InvokeActorRequest) (o
 		Data:      in.Data,
 	}
 
-	resp, err := c.protoClient.InvokeActor(ctx, req)
+	resp, err := callDapr(ctx, c, "InvokeActor", req, c.protoClient.InvokeActor)
 	if err != nil {
 		return nil, fmt.Errorf("error invoking binding %s/%s: %w", in.ActorType, in.ActorID, err)
 	}
@@ -153,7 +170,7 @@ func (c *GRPCClient) RegisterActorReminder(ctx context.Context, in *RegisterActo
 		Data:      in.Data,
 	}
 
-	_, err = c.protoClient.RegisterActorReminder(ctx, req)
+	_, err = callDapr(ctx, c, "RegisterActorReminder", req, c.protoClient.RegisterActorReminder)
 	if err != nil {
 		return fmt.Errorf("error invoking register actor reminder %s/%s: %w", in.ActorType, in.ActorID, err)
 	}
@@ -187,7 +204,7 @@ func (c *GRPCClient) UnregisterActorReminder(ctx context.Context, in *Unregister
 		Name:      in.Name,
 	}
 
-	_, err := c.protoClient.UnregisterActorReminder(ctx, req)
+	_, err := callDapr(ctx, c, "UnregisterActorReminder", req, c.protoClient.UnregisterActorReminder)
 	if err != nil {
 		return fmt.Errorf("error invoking unregister actor reminder %s/%s: %w", in.ActorType, in.ActorID, err)
 	}
@@ -235,7 +252,7 @@ func (c *GRPCClient) RegisterActorTimer(ctx context.Context, in *RegisterActorTi
 		Callback:  in.CallBack,
 	}
 
-	_, err = c.protoClient.RegisterActorTimer(ctx, req)
+	_, err = callDapr(ctx, c, "RegisterActorTimer", req, c.protoClient.RegisterActorTimer)
 	if err != nil {
 		return fmt.Errorf("error invoking actor register timer %s/%s: %w", in.ActorType, in.ActorID, err)
 	}
@@ -269,7 +286,7 @@ func (c *GRPCClient) UnregisterActorTimer(ctx context.Context, in *UnregisterAct
 		Name:      in.Name,
 	}
 
-	_, err := c.protoClient.UnregisterActorTimer(ctx, req)
+	_, err := callDapr(ctx, c, "UnregisterActorTimer", req, c.protoClient.UnregisterActorTimer)
 	if err != nil {
 		return fmt.Errorf("error invoking binding %s/%s: %w", in.ActorType, in.ActorID, err)
 	}
@@ -413,11 +430,11 @@ func (c *GRPCClient) GetActorState(ctx context.Context, in *GetActorStateRequest
 	if in.KeyName == "" {
 		return nil, errors.New("actor get state invocation keyName required")
 	}
-	rsp, err := c.protoClient.GetActorState(ctx, &pb.GetActorStateRequest{
+	rsp, err := callDapr(ctx, c, "GetActorState", &pb.GetActorStateRequest{
 		ActorId:   in.ActorID,
 		ActorType: in.ActorType,
 		Key:       in.KeyName,
-	})
+	}, c.protoClient.GetActorState)
 	if err != nil {
 		return nil, fmt.Errorf("error invoking actor get state %s/%s: %w", in.ActorType, in.ActorID, err)
 	}
@@ -457,10 +474,10 @@ func (c *GRPCClient) SaveStateTransactionally(ctx context.Context, actorType, ac
 			Metadata: metadata,
 		})
 	}
-	_, err := c.protoClient.ExecuteActorStateTransaction(ctx, &pb.ExecuteActorStateTransactionRequest{
+	_, err := callDapr(ctx, c, "ExecuteActorStateTransaction", &pb.ExecuteActorStateTransactionRequest{
 		ActorType:  actorType,
 		ActorId:    actorID,
 		Operations: grpcOperations,
-	})
+	}, c.protoClient.ExecuteActorStateTransaction)
 	return err
 }
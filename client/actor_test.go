@@ -20,6 +20,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
 
 const testActorType = "test"
@@ -73,6 +77,41 @@ func TestInvokeActor(t *testing.T) {
 	})
 }
 
+type captureActorClient struct {
+	pb.DaprClient
+	md metadata.MD
+}
+
+func (c *captureActorClient) InvokeActor(ctx context.Context, in *pb.InvokeActorRequest, opts ...grpc.CallOption) (*pb.InvokeActorResponse, error) {
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	return &pb.InvokeActorResponse{}, nil
+}
+
+func TestInvokeActorWithReentrancy(t *testing.T) {
+	in := &InvokeActorRequest{
+		ActorID:   "fn",
+		Method:    "mockMethod",
+		ActorType: testActorType,
+	}
+
+	t.Run("reentrancy ID is sent when set in context", func(t *testing.T) {
+		proto := &captureActorClient{}
+		c := &GRPCClient{protoClient: proto}
+		ctx := WithActorReentrancy(context.Background(), "reentrant-1")
+		_, err := c.InvokeActor(ctx, in)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"reentrant-1"}, proto.md.Get(actorReentrancyIDKey))
+	})
+
+	t.Run("reentrancy ID is not sent by default", func(t *testing.T) {
+		proto := &captureActorClient{}
+		c := &GRPCClient{protoClient: proto}
+		_, err := c.InvokeActor(context.Background(), in)
+		require.NoError(t, err)
+		assert.Empty(t, proto.md.Get(actorReentrancyIDKey))
+	})
+}
+
 func TestRegisterActorReminder(t *testing.T) {
 	ctx := context.Background()
 	in := &RegisterActorReminderRequest{
@@ -43,7 +43,8 @@ type BindingEvent struct {
 
 // InvokeBinding invokes specific operation on the configured Dapr binding.
 // This method covers input, output, and bi-directional bindings.
-func (c *GRPCClient) InvokeBinding(ctx context.Context, in *InvokeBindingRequest) (*BindingEvent, error) {
+// Use WithBindingRetry and WithBindingCircuitBreaker to make calls to flaky external systems resilient.
+func (c *GRPCClient) InvokeBinding(ctx context.Context, in *InvokeBindingRequest, opts ...BindingOption) (*BindingEvent, error) {
 	if in == nil {
 		return nil, errors.New("binding invocation required")
 	}
@@ -61,9 +62,27 @@ func (c *GRPCClient) InvokeBinding(ctx context.Context, in *InvokeBindingRequest
 		Metadata:  in.Metadata,
 	}
 
-	resp, err := c.protoClient.InvokeBinding(ctx, req)
+	o := &bindingOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.breaker != nil {
+		if err := o.breaker.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if o.retry == nil && o.breaker == nil {
+		return c.invokeBinding(ctx, req)
+	}
+	return c.invokeWithResilience(ctx, in, o, func() (*BindingEvent, error) {
+		return c.invokeBinding(ctx, req)
+	})
+}
+
+func (c *GRPCClient) invokeBinding(ctx context.Context, req *pb.InvokeBindingRequest) (*BindingEvent, error) {
+	resp, err := callDapr(ctx, c, "InvokeBinding", req, c.protoClient.InvokeBinding)
 	if err != nil {
-		return nil, fmt.Errorf("error invoking binding %s/%s: %w", in.Name, in.Operation, err)
+		return nil, fmt.Errorf("error invoking binding %s/%s: %w", req.GetName(), req.GetOperation(), err)
 	}
 
 	if resp != nil {
@@ -78,8 +97,8 @@ func (c *GRPCClient) InvokeBinding(ctx context.Context, in *InvokeBindingRequest
 
 // InvokeOutputBinding invokes configured Dapr binding with data (allows nil).InvokeOutputBinding
 // This method differs from InvokeBinding in that it doesn't expect any content being returned from the invoked method.
-func (c *GRPCClient) InvokeOutputBinding(ctx context.Context, in *InvokeBindingRequest) error {
-	if _, err := c.InvokeBinding(ctx, in); err != nil {
+func (c *GRPCClient) InvokeOutputBinding(ctx context.Context, in *InvokeBindingRequest, opts ...BindingOption) error {
+	if _, err := c.InvokeBinding(ctx, in, opts...); err != nil {
 		return fmt.Errorf("error invoking output binding: %w", err)
 	}
 	return nil
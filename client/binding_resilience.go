@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is returned when a binding call is short-circuited by an open circuit breaker.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BindingRetryPolicy configures the retries of a binding invocation.
+type BindingRetryPolicy struct {
+	// MaxRetries is the number of retries after the first failed attempt.
+	MaxRetries int
+	// Backoff is the delay before the first retry, it doubles with every further retry.
+	Backoff time.Duration
+	// Retryable reports whether an error is retried. By default all errors are retried
+	// except invalid arguments and cancelled or expired contexts.
+	Retryable func(error) bool
+}
+
+// BindingCircuitBreakerConfig configures the circuit breaker of a binding invocation.
+type BindingCircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit, it must be at least 1.
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a trial call is let through, it must be positive.
+	Cooldown time.Duration
+}
+
+func (c *BindingCircuitBreakerConfig) validate() error {
+	if c.FailureThreshold < 1 {
+		return fmt.Errorf("invalid circuit breaker failure threshold %d: must be at least 1", c.FailureThreshold)
+	}
+	if c.Cooldown <= 0 {
+		return fmt.Errorf("invalid circuit breaker cooldown %s: must be positive", c.Cooldown)
+	}
+	return nil
+}
+
+// BindingOption configures a binding invocation.
+type BindingOption func(*bindingOptions)
+
+type bindingOptions struct {
+	retry   *BindingRetryPolicy
+	breaker *BindingCircuitBreakerConfig
+}
+
+// WithBindingRetry retries failed binding invocations according to policy.
+func WithBindingRetry(policy BindingRetryPolicy) BindingOption {
+	return func(o *bindingOptions) {
+		o.retry = &policy
+	}
+}
+
+// WithBindingCircuitBreaker guards the binding invocation with a circuit breaker which opens after
+// the configured number of consecutive failures, calls then fail with ErrCircuitOpen until the
+// cooldown elapses. Only failures of the sidecar or the binding are counted, not invalid arguments
+// nor cancelled or expired contexts. The circuit state is kept by the client per binding name and operation,
+// use the same configuration for all calls of a binding operation. Invocations with a threshold lower than 1
+// or a non-positive cooldown fail without calling the binding.
+func WithBindingCircuitBreaker(config BindingCircuitBreakerConfig) BindingOption {
+	return func(o *bindingOptions) {
+		o.breaker = &config
+	}
+}
+
+func defaultBindingRetryable(err error) bool {
+	return isBindingFailure(err)
+}
+
+// isBindingFailure reports whether err is a failure of the sidecar or the binding, rather than
+// of the caller.
+func isBindingFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.Canceled, codes.DeadlineExceeded:
+		return false
+	default:
+		return true
+	}
+}
+
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+// allow reports whether a call may proceed, letting a single trial call through once the cooldown elapsed.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(b.openUntil) || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// release ends a trial call without counting its outcome, the next call is then a trial again.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+func (b *circuitBreaker) record(err error, config *BindingCircuitBreakerConfig, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if err == nil {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= config.FailureThreshold {
+		b.openUntil = now.Add(config.Cooldown)
+	}
+}
+
+// circuitBreakers holds the circuit breakers of a client keyed per binding name and operation.
+type circuitBreakers struct {
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func newCircuitBreakers() *circuitBreakers {
+	return &circuitBreakers{breakers: make(map[string]*circuitBreaker)}
+}
+
+func (cb *circuitBreakers) get(name, operation string) *circuitBreaker {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	key := name + "/" + operation
+	b, ok := cb.breakers[key]
+	if !ok {
+		b = &circuitBreaker{}
+		cb.breakers[key] = b
+	}
+	return b
+}
+
+// invokeWithResilience calls invoke applying the retry policy and circuit breaker of o.
+func (c *GRPCClient) invokeWithResilience(ctx context.Context, in *InvokeBindingRequest, o *bindingOptions, invoke func() (*BindingEvent, error)) (*BindingEvent, error) {
+	var breaker *circuitBreaker
+	if o.breaker != nil && c.bindingBreakers != nil {
+		breaker = c.bindingBreakers.get(in.Name, in.Operation)
+	}
+	retry := o.retry
+	if retry == nil {
+		retry = &BindingRetryPolicy{}
+	}
+	retryable := retry.Retryable
+	if retryable == nil {
+		retryable = defaultBindingRetryable
+	}
+
+	backoff := retry.Backoff
+	for attempt := 0; ; attempt++ {
+		if breaker != nil && !breaker.allow(time.Now()) {
+			return nil, ErrCircuitOpen
+		}
+		out, err := invoke()
+		if breaker != nil {
+			if err != nil && !isBindingFailure(err) {
+				breaker.release()
+			} else {
+				breaker.record(err, o.breaker, time.Now())
+			}
+		}
+		if err == nil || attempt >= retry.MaxRetries || !retryable(err) {
+			return out, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
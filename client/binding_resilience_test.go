@@ -0,0 +1,166 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// flakyBindingClient fails the first failures calls of each binding.
+type flakyBindingClient struct {
+	pb.DaprClient
+	failures int
+	code     codes.Code
+	calls    map[string]int
+}
+
+func (c *flakyBindingClient) InvokeBinding(ctx context.Context, in *pb.InvokeBindingRequest, opts ...grpc.CallOption) (*pb.InvokeBindingResponse, error) {
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[in.GetName()]++
+	if c.calls[in.GetName()] <= c.failures {
+		return nil, status.Error(c.code, "external system failed")
+	}
+	return &pb.InvokeBindingResponse{Data: []byte("ok")}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestInvokeBindingResilience$
+func TestInvokeBindingResilience(t *testing.T) {
+	ctx := context.Background()
+	in := &InvokeBindingRequest{Name: "test", Operation: "fn"}
+	retry := WithBindingRetry(BindingRetryPolicy{MaxRetries: 3, Backoff: time.Millisecond})
+
+	t.Run("retry then success", func(t *testing.T) {
+		proto := &flakyBindingClient{failures: 2, code: codes.Unavailable}
+		c := &GRPCClient{protoClient: proto, bindingBreakers: newCircuitBreakers()}
+		out, err := c.InvokeBinding(ctx, in, retry)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("ok"), out.Data)
+		assert.Equal(t, 3, proto.calls["test"])
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		proto := &flakyBindingClient{failures: 10, code: codes.Unavailable}
+		c := &GRPCClient{protoClient: proto, bindingBreakers: newCircuitBreakers()}
+		err := c.InvokeOutputBinding(ctx, in, retry)
+		require.Error(t, err)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, 4, proto.calls["test"])
+	})
+
+	t.Run("invalid argument is not retried", func(t *testing.T) {
+		proto := &flakyBindingClient{failures: 1, code: codes.InvalidArgument}
+		c := &GRPCClient{protoClient: proto, bindingBreakers: newCircuitBreakers()}
+		_, err := c.InvokeBinding(ctx, in, retry)
+		require.Error(t, err)
+		assert.Equal(t, 1, proto.calls["test"])
+	})
+
+	t.Run("circuit trips and recovers after cooldown", func(t *testing.T) {
+		proto := &flakyBindingClient{failures: 2, code: codes.Unavailable}
+		c := &GRPCClient{protoClient: proto, bindingBreakers: newCircuitBreakers()}
+		breaker := WithBindingCircuitBreaker(BindingCircuitBreakerConfig{FailureThreshold: 2, Cooldown: 50 * time.Millisecond})
+
+		for i := 0; i < 2; i++ {
+			_, err := c.InvokeBinding(ctx, in, breaker)
+			require.Error(t, err)
+		}
+		_, err := c.InvokeBinding(ctx, in, breaker)
+		require.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, 2, proto.calls["test"])
+
+		other := &InvokeBindingRequest{Name: "other", Operation: "fn"}
+		_, err = c.InvokeBinding(ctx, other, breaker)
+		require.NotErrorIs(t, err, ErrCircuitOpen)
+
+		time.Sleep(60 * time.Millisecond)
+		out, err := c.InvokeBinding(ctx, in, breaker)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("ok"), out.Data)
+	})
+
+	t.Run("caller errors are not counted", func(t *testing.T) {
+		proto := &flakyBindingClient{failures: 10, code: codes.InvalidArgument}
+		c := &GRPCClient{protoClient: proto, bindingBreakers: newCircuitBreakers()}
+		breaker := WithBindingCircuitBreaker(BindingCircuitBreakerConfig{FailureThreshold: 2, Cooldown: time.Minute})
+		for i := 0; i < 3; i++ {
+			_, err := c.InvokeBinding(ctx, in, breaker)
+			require.NotErrorIs(t, err, ErrCircuitOpen)
+		}
+		proto.code = codes.Canceled
+		for i := 0; i < 3; i++ {
+			_, err := c.InvokeBinding(ctx, in, breaker)
+			require.NotErrorIs(t, err, ErrCircuitOpen)
+		}
+		assert.Equal(t, 6, proto.calls["test"])
+	})
+
+	t.Run("cancelled trial call releases the trial", func(t *testing.T) {
+		proto := &flakyBindingClient{failures: 2, code: codes.Unavailable}
+		c := &GRPCClient{protoClient: proto, bindingBreakers: newCircuitBreakers()}
+		breaker := WithBindingCircuitBreaker(BindingCircuitBreakerConfig{FailureThreshold: 2, Cooldown: 10 * time.Millisecond})
+		for i := 0; i < 2; i++ {
+			_, err := c.InvokeBinding(ctx, in, breaker)
+			require.Error(t, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		proto.failures, proto.code = 3, codes.DeadlineExceeded
+		_, err := c.InvokeBinding(ctx, in, breaker)
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+		// The cancelled trial neither reopened the circuit nor kept further trials out.
+		proto.failures = 0
+		out, err := c.InvokeBinding(ctx, in, breaker)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("ok"), out.Data)
+	})
+
+	t.Run("open circuit stops retries", func(t *testing.T) {
+		proto := &flakyBindingClient{failures: 10, code: codes.Unavailable}
+		c := &GRPCClient{protoClient: proto, bindingBreakers: newCircuitBreakers()}
+		breaker := WithBindingCircuitBreaker(BindingCircuitBreakerConfig{FailureThreshold: 2, Cooldown: time.Minute})
+		_, err := c.InvokeBinding(ctx, in, retry, breaker)
+		require.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, 2, proto.calls["test"])
+	})
+
+	t.Run("invalid circuit breaker configs are rejected", func(t *testing.T) {
+		for name, config := range map[string]BindingCircuitBreakerConfig{
+			"zero threshold":     {Cooldown: time.Minute},
+			"negative threshold": {FailureThreshold: -1, Cooldown: time.Minute},
+			"zero cooldown":      {FailureThreshold: 2},
+			"negative cooldown":  {FailureThreshold: 2, Cooldown: -time.Second},
+		} {
+			t.Run(name, func(t *testing.T) {
+				proto := &flakyBindingClient{}
+				c := &GRPCClient{protoClient: proto, bindingBreakers: newCircuitBreakers()}
+				_, err := c.InvokeBinding(ctx, in, WithBindingCircuitBreaker(config))
+				require.ErrorContains(t, err, "invalid circuit breaker")
+				assert.Empty(t, proto.calls)
+			})
+		}
+	})
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CallOption configures the calls made to the Dapr API, whatever their domain. Unlike the
+// domain-specific options, such as StateOption or PublishEventOption, call options are carried
+// by the context with WithCallOptions and apply to every call made with that context.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	meta    map[string]string
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a copy of ctx carrying opts, which are added to the call options
+// already carried by ctx.
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	o := &callOptions{}
+	if parent, ok := ctx.Value(callOptionsKey{}).(*callOptions); ok {
+		*o = *parent
+		o.meta = maps.Clone(parent.meta)
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return context.WithValue(ctx, callOptionsKey{}, o)
+}
+
+// WithMetadata adds meta to the metadata of the requests which carry metadata, such as the requests
+// of state, pubsub, bindings and secrets, and to the metadata of the items of bulk requests.
+// Metadata set explicitly on a request takes precedence.
+func WithMetadata(meta map[string]string) CallOption {
+	return func(o *callOptions) {
+		if o.meta == nil {
+			o.meta = make(map[string]string, len(meta))
+		}
+		maps.Copy(o.meta, meta)
+	}
+}
+
+// WithTimeout bounds every call, including its retries, to d.
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithRetry retries the calls failing because the sidecar is unavailable up to retries times,
+// waiting backoff before the first retry and doubling it after each one. Calls which aren't
+// idempotent, such as publishing an event, may then be applied more than once.
+func WithRetry(retries int, backoff time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.retries = retries
+		o.backoff = backoff
+	}
+}
+
+// prepareCall applies the call options carried by ctx to req, it returns the context of the call
+// and the function running it with the retries of the options.
+func prepareCall[Req, Resp any](ctx context.Context, req Req, call func(context.Context, Req, ...grpc.CallOption) (Resp, error)) (context.Context, context.CancelFunc, func() (Resp, error)) {
+	o, ok := ctx.Value(callOptionsKey{}).(*callOptions)
+	if !ok {
+		return ctx, func() {}, func() (Resp, error) {
+			return call(ctx, req)
+		}
+	}
+	if m, ok := any(req).(proto.Message); ok && len(o.meta) > 0 {
+		mergeRequestMetadata(m.ProtoReflect(), o.meta, true)
+	}
+	cancel := context.CancelFunc(func() {})
+	if o.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+	}
+	run := func() (Resp, error) {
+		backoff := o.backoff
+		for attempt := 0; ; attempt++ {
+			resp, err := call(ctx, req)
+			if err == nil || attempt >= o.retries || status.Code(err) != codes.Unavailable {
+				return resp, err
+			}
+			select {
+			case <-ctx.Done():
+				return resp, errors.Join(err, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+	return ctx, cancel, run
+}
+
+// mergeRequestMetadata adds meta to the string map field named metadata of msg, keeping the
+// entries already set. When items is true, it also merges meta into the messages of the list
+// fields of msg, such as the states of a SaveStateRequest.
+func mergeRequestMetadata(msg protoreflect.Message, meta map[string]string, items bool) {
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		switch {
+		case fd.IsMap() && fd.Name() == "metadata" &&
+			fd.MapKey().Kind() == protoreflect.StringKind && fd.MapValue().Kind() == protoreflect.StringKind:
+			// The map may be owned by the caller, so a new one is set rather than mutating it.
+			merged := msg.NewField(fd).Map()
+			for k, v := range meta {
+				merged.Set(protoreflect.ValueOfString(k).MapKey(), protoreflect.ValueOfString(v))
+			}
+			msg.Get(fd).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				merged.Set(k, v)
+				return true
+			})
+			msg.Set(fd, protoreflect.ValueOfMap(merged))
+		case items && fd.IsList() && fd.Kind() == protoreflect.MessageKind:
+			list := msg.Get(fd).List()
+			for j := 0; j < list.Len(); j++ {
+				mergeRequestMetadata(list.Get(j).Message(), meta, false)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// callRecordingClient records the metadata and deadline of the requests of several domains.
+// The first failures calls fail with codes.Unavailable.
+type callRecordingClient struct {
+	pb.DaprClient
+	failures  int
+	calls     int
+	metadata  []map[string]string
+	deadlines []bool
+}
+
+func (c *callRecordingClient) record(ctx context.Context, meta map[string]string) error {
+	c.calls++
+	_, hasDeadline := ctx.Deadline()
+	c.metadata = append(c.metadata, meta)
+	c.deadlines = append(c.deadlines, hasDeadline)
+	if c.calls <= c.failures {
+		return status.Error(codes.Unavailable, "sidecar unavailable")
+	}
+	return nil
+}
+
+func (c *callRecordingClient) GetState(ctx context.Context, in *pb.GetStateRequest, opts ...grpc.CallOption) (*pb.GetStateResponse, error) {
+	return &pb.GetStateResponse{}, c.record(ctx, in.GetMetadata())
+}
+
+func (c *callRecordingClient) SaveState(ctx context.Context, in *pb.SaveStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, c.record(ctx, in.GetStates()[0].GetMetadata())
+}
+
+func (c *callRecordingClient) PublishEvent(ctx context.Context, in *pb.PublishEventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, c.record(ctx, in.GetMetadata())
+}
+
+func (c *callRecordingClient) GetSecret(ctx context.Context, in *pb.GetSecretRequest, opts ...grpc.CallOption) (*pb.GetSecretResponse, error) {
+	return &pb.GetSecretResponse{}, c.record(ctx, in.GetMetadata())
+}
+
+func (c *callRecordingClient) InvokeService(ctx context.Context, in *pb.InvokeServiceRequest, opts ...grpc.CallOption) (*commonv1pb.InvokeResponse, error) {
+	return &commonv1pb.InvokeResponse{}, c.record(ctx, nil)
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestCallOptions$
+func TestCallOptions(t *testing.T) {
+	t.Run("metadata applies to every domain", func(t *testing.T) {
+		fake := &callRecordingClient{}
+		c := &GRPCClient{protoClient: fake}
+		ctx := WithCallOptions(context.Background(), WithMetadata(map[string]string{"tenant": "a", "region": "eu"}))
+		explicit := map[string]string{"region": "us"}
+
+		_, err := c.GetState(ctx, testStore, "key1", explicit)
+		require.NoError(t, err)
+		require.NoError(t, c.SaveState(ctx, testStore, "key1", []byte("v"), nil))
+		require.NoError(t, c.PublishEvent(ctx, "messages", "orders", []byte("v")))
+		_, err = c.GetSecret(ctx, "secrets", "key1", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []map[string]string{
+			{"tenant": "a", "region": "us"},
+			{"tenant": "a", "region": "eu"},
+			{"tenant": "a", "region": "eu"},
+			{"tenant": "a", "region": "eu"},
+		}, fake.metadata)
+		assert.Equal(t, map[string]string{"region": "us"}, explicit)
+	})
+
+	t.Run("options are added to the parent ones", func(t *testing.T) {
+		fake := &callRecordingClient{}
+		c := &GRPCClient{protoClient: fake}
+		parent := WithCallOptions(context.Background(), WithMetadata(map[string]string{"tenant": "a"}))
+		ctx := WithCallOptions(parent, WithMetadata(map[string]string{"region": "eu"}))
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		_, err = c.GetState(parent, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]string{{"tenant": "a", "region": "eu"}, {"tenant": "a"}}, fake.metadata)
+	})
+
+	t.Run("timeout applies to every domain", func(t *testing.T) {
+		fake := &callRecordingClient{}
+		c := &GRPCClient{protoClient: fake}
+		ctx := WithCallOptions(context.Background(), WithTimeout(time.Second))
+		_, err := c.GetSecret(ctx, "secrets", "key1", nil)
+		require.NoError(t, err)
+		_, err = c.InvokeMethod(ctx, "app", "method", "post")
+		require.NoError(t, err)
+		_, err = c.GetSecret(context.Background(), "secrets", "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true, true, false}, fake.deadlines)
+	})
+
+	t.Run("unavailable calls are retried", func(t *testing.T) {
+		fake := &callRecordingClient{failures: 2}
+		c := &GRPCClient{protoClient: fake}
+		ctx := WithCallOptions(context.Background(), WithRetry(2, time.Millisecond))
+		_, err := c.InvokeMethod(ctx, "app", "method", "post")
+		require.NoError(t, err)
+		assert.Equal(t, 3, fake.calls)
+
+		fake = &callRecordingClient{failures: 2}
+		c.protoClient = fake
+		ctx = WithCallOptions(context.Background(), WithRetry(1, time.Millisecond))
+		require.Error(t, c.PublishEvent(ctx, "messages", "orders", []byte("v")))
+		assert.Equal(t, 2, fake.calls)
+	})
+
+	t.Run("calls are not retried by default", func(t *testing.T) {
+		fake := &callRecordingClient{failures: 1}
+		c := &GRPCClient{protoClient: fake}
+		_, err := c.GetState(context.Background(), testStore, "key1", nil)
+		require.Error(t, err)
+		assert.Equal(t, 1, fake.calls)
+	})
+}
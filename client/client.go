@@ -30,9 +30,11 @@ import (
 	"github.com/dapr/go-sdk/actor"
 	"github.com/dapr/go-sdk/actor/config"
 	"github.com/dapr/go-sdk/client/internal"
+	"github.com/dapr/go-sdk/service/common"
 	"github.com/dapr/go-sdk/version"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
@@ -79,11 +81,11 @@ func SetLogger(l *log.Logger) {
 type Client interface {
 	// InvokeBinding invokes specific operation on the configured Dapr binding.
 	// This method covers input, output, and bi-directional bindings.
-	InvokeBinding(ctx context.Context, in *InvokeBindingRequest) (out *BindingEvent, err error)
+	InvokeBinding(ctx context.Context, in *InvokeBindingRequest, opts ...BindingOption) (out *BindingEvent, err error)
 
 	// InvokeOutputBinding invokes configured Dapr binding with data.InvokeOutputBinding
 	// This method differs from InvokeBinding in that it doesn't expect any content being returned from the invoked method.
-	InvokeOutputBinding(ctx context.Context, in *InvokeBindingRequest) error
+	InvokeOutputBinding(ctx context.Context, in *InvokeBindingRequest, opts ...BindingOption) error
 
 	// InvokeMethod invokes service without raw data
 	InvokeMethod(ctx context.Context, appID, methodName, verb string) (out []byte, err error)
@@ -94,15 +96,24 @@ type Client interface {
 	// InvokeMethodWithCustomContent invokes app with custom content (struct + content type).
 	InvokeMethodWithCustomContent(ctx context.Context, appID, methodName, verb string, contentType string, content interface{}) (out []byte, err error)
 
+	// InvokeMethodFanOut invokes method on several apps concurrently and returns the result of each app.
+	InvokeMethodFanOut(ctx context.Context, appIDs []string, method, verb string, data []byte, opts ...FanOutOption) (map[string]InvokeResult, error)
+
 	// GetMetadata returns metadata from the sidecar.
 	GetMetadata(ctx context.Context) (metadata *GetMetadataResponse, err error)
 
+	// Ping measures the roundtrip latency to the sidecar.
+	Ping(ctx context.Context) (time.Duration, error)
+
 	// SetMetadata sets a key-value pair in the sidecar.
 	SetMetadata(ctx context.Context, key, value string) error
 
 	// PublishEvent publishes data onto topic in specific pubsub component.
 	PublishEvent(ctx context.Context, pubsubName, topicName string, data interface{}, opts ...PublishEventOption) error
 
+	// ForwardMessage republishes a received topic event onto another pubsub topic.
+	ForwardMessage(ctx context.Context, e *common.TopicEvent, pubsubName, topicName string, opts ...PublishEventOption) error
+
 	// PublishEventfromCustomContent serializes an struct and publishes its contents as data (JSON) onto topic in specific pubsub component.
 	// Deprecated: This method is deprecated and will be removed in a future version of the SDK. Please use `PublishEvent` instead.
 	PublishEventfromCustomContent(ctx context.Context, pubsubName, topicName string, data interface{}) error
@@ -118,21 +129,54 @@ type Client interface {
 	// GetBulkSecret retrieves all preconfigured secrets for this application.
 	GetBulkSecret(ctx context.Context, storeName string, meta map[string]string) (data map[string]map[string]string, err error)
 
+	// GetSecretWithFallback retrieves a secret, invoking fallback when the store isn't configured or the secret doesn't exist.
+	GetSecretWithFallback(ctx context.Context, storeName, key string, meta map[string]string, fallback func() (map[string]string, error)) (map[string]string, error)
+
+	// SubscribeSecrets polls the given secrets and invokes handler whenever one of them changes.
+	SubscribeSecrets(ctx context.Context, storeName string, keys []string, handler SecretChangeHandler, opts ...SecretSubscribeOption) error
+
 	// SaveState saves the raw data into store using default state options.
 	SaveState(ctx context.Context, storeName, key string, data []byte, meta map[string]string, so ...StateOption) error
 
+	// SaveStateKey saves the raw data into store under a composite key using default state options.
+	SaveStateKey(ctx context.Context, storeName string, key CompositeKey, data []byte, meta map[string]string, so ...StateOption) error
+
+	// SaveStateChanged saves the raw data into store and reports whether a write occurred.
+	SaveStateChanged(ctx context.Context, storeName, key string, data []byte, meta map[string]string, so ...StateOption) (bool, error)
+
 	// SaveState saves the raw data into store using provided state options and etag.
 	SaveStateWithETag(ctx context.Context, storeName, key string, data []byte, etag string, meta map[string]string, so ...StateOption) error
 
 	// SaveBulkState saves multiple state item to store with specified options.
 	SaveBulkState(ctx context.Context, storeName string, items ...*SetStateItem) error
 
+	// SaveBulkStateChunked saves items to store in chunks whose serialized size stays under maxBytes.
+	SaveBulkStateChunked(ctx context.Context, storeName string, items []*SetStateItem, maxBytes int, opts ...StateOption) error
+
+	// WithTransaction reads keys, runs fn on the snapshot and commits its writes and deletes atomically, retrying on conflict.
+	WithTransaction(ctx context.Context, storeName string, keys []string, fn TransactionFunc, opts ...TransactionOption) (map[string][]byte, error)
+
+	// CompareAndSwapState writes newValue to key only if its current value equals expected.
+	CompareAndSwapState(ctx context.Context, storeName, key string, expected, newValue []byte, opts ...StateOption) (bool, error)
+
+	// StateConflictStats returns the number of ETag conflicts met by the optimistic state helpers.
+	StateConflictStats() StateConflictStats
+
+	// RateLimitStats returns the activity of the rate limiter of the client.
+	RateLimitStats() RateLimitStats
+
 	// GetState retrieves state from specific store using default consistency option.
 	GetState(ctx context.Context, storeName, key string, meta map[string]string) (item *StateItem, err error)
 
+	// GetStateKey retrieves state stored under a composite key using default consistency option.
+	GetStateKey(ctx context.Context, storeName string, key CompositeKey, meta map[string]string) (item *StateItem, err error)
+
 	// GetStateWithConsistency retrieves state from specific store using provided state consistency.
 	GetStateWithConsistency(ctx context.Context, storeName, key string, meta map[string]string, sc StateConsistency) (item *StateItem, err error)
 
+	// StateCacheStats returns the access statistics of the state cache enabled with WithStateCache.
+	StateCacheStats() StateCacheStats
+
 	// GetBulkState retrieves state for multiple keys from specific store.
 	GetBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string, parallelism int32) ([]*BulkStateItem, error)
 
@@ -142,6 +186,9 @@ type Client interface {
 	// DeleteState deletes content from store using default state options.
 	DeleteState(ctx context.Context, storeName, key string, meta map[string]string) error
 
+	// DeleteStateKey deletes content stored under a composite key using default state options.
+	DeleteStateKey(ctx context.Context, storeName string, key CompositeKey, meta map[string]string) error
+
 	// DeleteStateWithETag deletes content from store using provided state options and etag.
 	DeleteStateWithETag(ctx context.Context, storeName, key string, etag *ETag, meta map[string]string, opts *StateOptions) error
 
@@ -162,6 +209,12 @@ type Client interface {
 	// UnsubscribeConfigurationItems can stop the subscription with target store's and id
 	UnsubscribeConfigurationItems(ctx context.Context, storeName string, id string, opts ...ConfigurationOpt) error
 
+	// DeleteStateByPrefix deletes all keys of the store starting with prefix.
+	DeleteStateByPrefix(ctx context.Context, storeName, prefix string, opts ...DeleteByPrefixOption) (int, error)
+
+	// ListStateKeys returns all keys of the store starting with prefix.
+	ListStateKeys(ctx context.Context, storeName, prefix string, opts ...ListStateKeysOption) ([]string, error)
+
 	// DeleteBulkState deletes content for multiple keys from store.
 	DeleteBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string) error
 
@@ -194,6 +247,9 @@ type Client interface {
 	// WithAuthToken sets Dapr API token on the instantiated client.
 	WithAuthToken(token string)
 
+	// WithContext returns a client bound to ctx whose calls inherit the given metadata and timeout.
+	WithContext(ctx context.Context, opts ...ScopeOption) Client
+
 	// Close cleans up all resources created by the client.
 	Close()
 
@@ -242,6 +298,9 @@ type Client interface {
 	// RaiseEventWorkflowBeta1 raises an event for a workflow.
 	RaiseEventWorkflowBeta1(ctx context.Context, req *RaiseEventWorkflowRequest) error
 
+	// WatchConnectionState returns a channel receiving the state changes of the underlying gRPC connection.
+	WatchConnectionState(ctx context.Context) <-chan connectivity.State
+
 	// GrpcClient returns the base grpc client if grpc is used and nil otherwise
 	GrpcClient() pb.DaprClient
 
@@ -336,15 +395,16 @@ func NewClientWithAddressContext(ctx context.Context, address string) (client Cl
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
 	conn, err := grpc.DialContext(
-		ctx,
+		dialCtx,
 		parsedAddress.Target,
 		opts...,
 	)
 	cancel()
 	if err != nil {
-		return nil, fmt.Errorf("error creating connection to '%s': %w", address, err)
+		return nil, dialError(ctx, address, timeout, err)
 	}
 
 	return newClientWithConnection(conn, at), nil
@@ -386,16 +446,18 @@ func NewClientWithSocket(socket string) (client Client, err error) {
 	return newClientWithConnection(conn, at), nil
 }
 
-func newClientWithConnection(conn *grpc.ClientConn, authToken *authToken) Client {
+func newClientWithConnection(conn *grpc.ClientConn, authToken *authToken) *GRPCClient {
 	apiToken := os.Getenv(apiTokenEnvVarName)
 	if apiToken != "" {
 		logger.Println("client uses API token")
 		authToken.set(apiToken)
 	}
 	return &GRPCClient{
-		connection:  conn,
-		protoClient: pb.NewDaprClient(conn),
-		authToken:   authToken,
+		connection:      conn,
+		protoClient:     pb.NewDaprClient(conn),
+		authToken:       authToken,
+		bindingBreakers: newCircuitBreakers(),
+		stateConflicts:  &stateConflictCounters{},
 	}
 }
 
@@ -446,10 +508,54 @@ type GRPCClient struct {
 	connection  *grpc.ClientConn
 	protoClient pb.DaprClient
 	authToken   *authToken
+
+	traceStateKeys     traceKeysMode
+	stateKeyValidation bool
+	defaultStateStore  string
+	stateEncryption    *stateEncryption
+	middleware         []RequestMiddleware
+	metrics            MetricsObserver
+
+	// pool is set when the client distributes calls across several connections,
+	// connection is then the first connection of the pool.
+	pool *connPool
+
+	stateCache *stateCache
+
+	// bindingBreakers holds the circuit breakers of binding invocations, it is shared with scoped clients.
+	bindingBreakers *circuitBreakers
+	// monitor resets the connections of pool when the sidecar stays unhealthy, it is set with WithAutoReconnect.
+	monitor *healthMonitor
+	// rateLimiter paces the calls to the Dapr API, it is shared with scoped clients.
+	rateLimiter *rateLimiter
+	// stateConflicts counts the ETag conflicts of the optimistic state helpers, it is shared with scoped clients.
+	stateConflicts *stateConflictCounters
+
+	// scope holds the defaults of a client returned by WithContext for a client without a connection,
+	// they are applied by callDapr as the connection can't apply them.
+	scope *scopedConn
+
+	// shared is set for clients which use the connection of another client and must not close it.
+	shared bool
 }
 
 // Close cleans up all resources created by the client.
 func (c *GRPCClient) Close() {
+	if c.shared {
+		return
+	}
+	if c.monitor != nil {
+		c.monitor.close()
+		c.monitor = nil
+	}
+	if c.pool != nil {
+		if err := c.pool.close(); err != nil {
+			logger.Printf("error closing connection pool: %v", err)
+		}
+		c.pool = nil
+		c.connection = nil
+		return
+	}
 	if c.connection != nil {
 		c.connection.Close()
 		c.connection = nil
@@ -474,20 +580,65 @@ func (c *GRPCClient) WithTraceID(ctx context.Context, id string) context.Context
 
 // Shutdown the sidecar.
 func (c *GRPCClient) Shutdown(ctx context.Context) error {
-	_, err := c.protoClient.Shutdown(ctx, &pb.ShutdownRequest{})
+	_, err := callDapr(ctx, c, "Shutdown", &pb.ShutdownRequest{}, c.protoClient.Shutdown)
 	if err != nil {
 		return fmt.Errorf("error shutting down the sidecar: %w", err)
 	}
 	return nil
 }
 
+// WatchConnectionState returns a channel which receives the current state of the underlying
+// gRPC connection followed by every subsequent state change.
+// The channel is closed when ctx is cancelled or the client is closed.
+// The state reflects the gRPC connection only, a Ready connection doesn't mean the sidecar is healthy.
+// Slow receivers may miss intermediate transitions, the latest state is always delivered.
+func (c *GRPCClient) WatchConnectionState(ctx context.Context) <-chan connectivity.State {
+	ch := make(chan connectivity.State, 1)
+	conn, pool := c.conn(), c.pool
+	go func() {
+		defer close(ch)
+		if conn == nil {
+			return
+		}
+		state := conn.GetState()
+		for {
+			select {
+			case ch <- state:
+			case <-ctx.Done():
+				return
+			}
+			if state == connectivity.Shutdown {
+				// A reset of the connection pool shuts the previous connection down, the watch
+				// then follows the connection replacing it.
+				if pool == nil || pool.currentConn() == conn {
+					return
+				}
+				conn = pool.currentConn()
+			} else if !conn.WaitForStateChange(ctx, state) {
+				return
+			}
+			state = conn.GetState()
+		}
+	}()
+	return ch
+}
+
 // GrpcClient returns the base grpc client.
 func (c *GRPCClient) GrpcClient() pb.DaprClient {
 	return c.protoClient
 }
 
-// GrpcClientConn returns the grpc.ClientConn object used by this client.
+// GrpcClientConn returns the grpc.ClientConn object used by this client. With a connection pool,
+// it is the first connection of the pool, which changes when WithAutoReconnect resets the pool.
 func (c *GRPCClient) GrpcClientConn() *grpc.ClientConn {
+	return c.conn()
+}
+
+// conn returns the current connection of the client, the first one of its pool if any.
+func (c *GRPCClient) conn() *grpc.ClientConn {
+	if c.pool != nil {
+		return c.pool.currentConn()
+	}
 	return c.connection
 }
 
@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -557,6 +558,38 @@ func (s *testDaprServer) RaiseEventWorkflowBeta1(ctx context.Context, in *pb.Rai
 	return &emptypb.Empty{}, nil
 }
 
+func TestWatchConnectionState(t *testing.T) {
+	t.Run("reports state changes and closes on cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		c, closer := getTestClient(ctx)
+		defer c.Close()
+
+		require.NoError(t, c.Shutdown(ctx))
+		states := c.WatchConnectionState(ctx)
+		assert.Equal(t, connectivity.Ready, <-states)
+
+		closer()
+		select {
+		case state := <-states:
+			assert.NotEqual(t, connectivity.Ready, state)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for state change")
+		}
+
+		cancel()
+		for range states {
+			// drain until closed
+		}
+	})
+
+	t.Run("closed for client without connection", func(t *testing.T) {
+		c := &GRPCClient{}
+		_, ok := <-c.WatchConnectionState(context.Background())
+		assert.False(t, ok)
+	})
+}
+
 func TestGrpcClient(t *testing.T) {
 	protoClient := pb.NewDaprClient(nil)
 	client := &GRPCClient{protoClient: protoClient}
@@ -40,11 +40,11 @@ func (c *GRPCClient) GetConfigurationItems(ctx context.Context, storeName string
 	for _, opt := range opts {
 		opt(metadata)
 	}
-	rsp, err := c.protoClient.GetConfiguration(ctx, &pb.GetConfigurationRequest{
+	rsp, err := callDapr(ctx, c, "GetConfiguration", &pb.GetConfigurationRequest{
 		StoreName: storeName,
 		Keys:      keys,
 		Metadata:  metadata,
-	})
+	}, c.protoClient.GetConfiguration)
 	if err != nil {
 		return nil, err
 	}
@@ -68,11 +68,11 @@ func (c *GRPCClient) SubscribeConfigurationItems(ctx context.Context, storeName
 		opt(metadata)
 	}
 
-	client, err := c.protoClient.SubscribeConfiguration(ctx, &pb.SubscribeConfigurationRequest{
+	client, err := callDapr(ctx, c, "SubscribeConfiguration", &pb.SubscribeConfigurationRequest{
 		StoreName: storeName,
 		Keys:      keys,
 		Metadata:  metadata,
-	})
+	}, c.protoClient.SubscribeConfiguration)
 	if err != nil {
 		return "", fmt.Errorf("subscribe configuration failed with error = %w", err)
 	}
@@ -112,10 +112,10 @@ func (c *GRPCClient) SubscribeConfigurationItems(ctx context.Context, storeName
 }
 
 func (c *GRPCClient) UnsubscribeConfigurationItems(ctx context.Context, storeName string, id string, opts ...ConfigurationOpt) error {
-	resp, err := c.protoClient.UnsubscribeConfiguration(ctx, &pb.UnsubscribeConfigurationRequest{
+	resp, err := callDapr(ctx, c, "UnsubscribeConfiguration", &pb.UnsubscribeConfigurationRequest{
 		StoreName: storeName,
 		Id:        id,
-	})
+	}, c.protoClient.UnsubscribeConfiguration)
 	if err != nil {
 		return fmt.Errorf("unsubscribe failed with error = %w", err)
 	}
@@ -39,7 +39,7 @@ func (c *GRPCClient) invokeServiceWithRequest(ctx context.Context, req *pb.Invok
 		return nil, errors.New("nil request")
 	}
 
-	resp, err := c.protoClient.InvokeService(ctx, req)
+	resp, err := callDapr(ctx, c, "InvokeService", req, c.protoClient.InvokeService)
 	if err != nil {
 		return nil, err
 	}
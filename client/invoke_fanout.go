@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const fanOutParallelismDefault = 10
+
+// InvokeResult is the outcome of the invocation of one app of InvokeMethodFanOut.
+type InvokeResult struct {
+	Data []byte
+	Err  error
+}
+
+// FanOutOption configures InvokeMethodFanOut.
+type FanOutOption func(*fanOutOptions)
+
+type fanOutOptions struct {
+	parallelism int
+	failFast    bool
+	contentType string
+}
+
+// WithFanOutParallelism sets how many apps are invoked concurrently, defaults to 10.
+func WithFanOutParallelism(n int) FanOutOption {
+	return func(o *fanOutOptions) {
+		o.parallelism = n
+	}
+}
+
+// WithFanOutFailFast cancels the pending invocations as soon as one fails, instead of collecting all results.
+func WithFanOutFailFast() FanOutOption {
+	return func(o *fanOutOptions) {
+		o.failFast = true
+	}
+}
+
+// WithFanOutContentType sets the content type of the data sent to every app.
+func WithFanOutContentType(contentType string) FanOutOption {
+	return func(o *fanOutOptions) {
+		o.contentType = contentType
+	}
+}
+
+// InvokeMethodFanOut invokes method on every app of appIDs concurrently, with the given data when not nil,
+// and returns the result of each app keyed by app ID.
+// By default all apps are invoked and the returned error joins the errors of the apps which failed.
+// With WithFanOutFailFast the invocations still pending are cancelled after the first failure, which is returned;
+// the results then hold the apps invoked so far, the cancelled ones reporting the cancellation error.
+func (c *GRPCClient) InvokeMethodFanOut(ctx context.Context, appIDs []string, method, verb string, data []byte, opts ...FanOutOption) (map[string]InvokeResult, error) {
+	if len(appIDs) == 0 {
+		return nil, errors.New("appIDs required")
+	}
+	seen := make(map[string]bool, len(appIDs))
+	for _, id := range appIDs {
+		if err := hasRequiredInvokeArgs(id, method, verb); err != nil {
+			return nil, fmt.Errorf("missing required parameter: %w", err)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate appID %s", id)
+		}
+		seen[id] = true
+	}
+	o := &fanOutOptions{parallelism: fanOutParallelismDefault}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.parallelism < 1 {
+		return nil, fmt.Errorf("invalid parallelism %d: must be at least 1", o.parallelism)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		results  = make(map[string]InvokeResult, len(appIDs))
+		errs     []error
+		firstErr error
+		sem      = make(chan struct{}, o.parallelism)
+	)
+	for _, id := range appIDs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			results[id] = InvokeResult{Err: ctx.Err()}
+			errs = append(errs, fmt.Errorf("error invoking %s on app %s: %w", method, id, ctx.Err()))
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			var (
+				out []byte
+				err error
+			)
+			if data != nil {
+				out, err = c.InvokeMethodWithContent(ctx, id, method, verb, &DataContent{Data: data, ContentType: o.contentType})
+			} else {
+				out, err = c.InvokeMethod(ctx, id, method, verb)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[id] = InvokeResult{Data: out, Err: err}
+			if err == nil {
+				return
+			}
+			err = fmt.Errorf("error invoking %s on app %s: %w", method, id, err)
+			errs = append(errs, err)
+			if o.failFast && firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return results, firstErr
+	}
+	return results, errors.Join(errs...)
+}
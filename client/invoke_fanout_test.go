@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// fanOutClient echoes the app ID and data, failing the calls to failApp.
+// Calls to slowApp block until their context is done.
+type fanOutClient struct {
+	pb.DaprClient
+	failApp  string
+	slowApp  string
+	inflight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (c *fanOutClient) InvokeService(ctx context.Context, in *pb.InvokeServiceRequest, opts ...grpc.CallOption) (*commonv1pb.InvokeResponse, error) {
+	n := c.inflight.Add(1)
+	defer c.inflight.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	switch in.GetId() {
+	case c.failApp:
+		return nil, status.Error(codes.Unavailable, "app unavailable")
+	case c.slowApp:
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	data := append([]byte(in.GetId()+":"), in.GetMessage().GetData().GetValue()...)
+	return &commonv1pb.InvokeResponse{Data: &anypb.Any{Value: data}}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestInvokeMethodFanOut$
+func TestInvokeMethodFanOut(t *testing.T) {
+	ctx := context.Background()
+	apps := []string{"a", "b", "c"}
+
+	t.Run("collect all", func(t *testing.T) {
+		fake := &fanOutClient{failApp: "b"}
+		c := &GRPCClient{protoClient: fake}
+		results, err := c.InvokeMethodFanOut(ctx, apps, "fn", "post", []byte("ping"), WithFanOutParallelism(2))
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "app b")
+		assert.Equal(t, codes.Unavailable, status.Code(results["b"].Err))
+
+		require.Len(t, results, 3)
+		require.NoError(t, results["a"].Err)
+		assert.Equal(t, []byte("a:ping"), results["a"].Data)
+		require.NoError(t, results["c"].Err)
+		assert.Equal(t, []byte("c:ping"), results["c"].Data)
+		assert.LessOrEqual(t, fake.peak.Load(), int32(2))
+	})
+
+	t.Run("all succeed", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &fanOutClient{}}
+		results, err := c.InvokeMethodFanOut(ctx, apps, "fn", "get", nil)
+		require.NoError(t, err)
+		assert.Len(t, results, 3)
+	})
+
+	t.Run("fail fast cancels pending calls", func(t *testing.T) {
+		fake := &fanOutClient{failApp: "b", slowApp: "c"}
+		c := &GRPCClient{protoClient: fake}
+		results, err := c.InvokeMethodFanOut(ctx, apps, "fn", "post", []byte("ping"), WithFanOutFailFast())
+		require.Error(t, err)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, codes.Canceled, status.Code(results["c"].Err))
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &fanOutClient{}}
+		_, err := c.InvokeMethodFanOut(ctx, nil, "fn", "get", nil)
+		require.Error(t, err)
+		_, err = c.InvokeMethodFanOut(ctx, []string{"a", "a"}, "fn", "get", nil)
+		require.Error(t, err)
+		_, err = c.InvokeMethodFanOut(ctx, apps, "", "get", nil)
+		require.Error(t, err)
+		_, err = c.InvokeMethodFanOut(ctx, apps, "fn", "get", nil, WithFanOutParallelism(0))
+		require.Error(t, err)
+	})
+}
@@ -62,7 +62,7 @@ func (c *GRPCClient) TryLockAlpha1(ctx context.Context, storeName string, reques
 		StoreName:       storeName,
 	}
 
-	resp, err := c.protoClient.TryLockAlpha1(ctx, &req)
+	resp, err := callDapr(ctx, c, "TryLockAlpha1", &req, c.protoClient.TryLockAlpha1)
 	if err != nil {
 		return nil, fmt.Errorf("error getting lock: %w", err)
 	}
@@ -88,7 +88,7 @@ func (c *GRPCClient) UnlockAlpha1(ctx context.Context, storeName string, request
 		StoreName:  storeName,
 	}
 
-	resp, err := c.protoClient.UnlockAlpha1(ctx, &req)
+	resp, err := callDapr(ctx, c, "UnlockAlpha1", &req, c.protoClient.UnlockAlpha1)
 	if err != nil {
 		return nil, fmt.Errorf("error getting lock: %w", err)
 	}
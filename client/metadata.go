@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
@@ -52,7 +53,7 @@ type MetadataHTTPEndpoint struct {
 
 // GetMetadata returns the metadata of the sidecar
 func (c *GRPCClient) GetMetadata(ctx context.Context) (metadata *GetMetadataResponse, err error) {
-	resp, err := c.protoClient.GetMetadata(ctx, &pb.GetMetadataRequest{})
+	resp, err := callDapr(ctx, c, "GetMetadata", &pb.GetMetadataRequest{}, c.protoClient.GetMetadata)
 	if err != nil {
 		return nil, fmt.Errorf("error invoking service: %w", err)
 	}
@@ -119,9 +120,20 @@ func (c *GRPCClient) SetMetadata(ctx context.Context, key, value string) error {
 		Key:   key,
 		Value: value,
 	}
-	_, err := c.protoClient.SetMetadata(ctx, req)
+	_, err := callDapr(ctx, c, "SetMetadata", req, c.protoClient.SetMetadata)
 	if err != nil {
 		return fmt.Errorf("error setting metadata: %w", err)
 	}
 	return nil
 }
+
+// Ping measures the roundtrip latency to the sidecar.
+// It calls the GetMetadata API, the lightest call every sidecar serves, so frequent probes add load
+// to that endpoint. The returned duration also includes any client interceptors.
+func (c *GRPCClient) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	if _, err := callDapr(ctx, c, "GetMetadata", &pb.GetMetadataRequest{}, c.protoClient.GetMetadata); err != nil {
+		return 0, fmt.Errorf("error pinging sidecar: %w", err)
+	}
+	return time.Since(start), nil
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -29,3 +30,19 @@ func TestSetMetadata(t *testing.T) {
 		assert.Equal(t, "test_value", metadata.ExtendedMetadata["test_key"])
 	})
 }
+
+func TestPing(t *testing.T) {
+	ctx := context.Background()
+	t.Run("ping", func(t *testing.T) {
+		d, err := testClient.Ping(ctx)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+	})
+
+	t.Run("ping cancelled", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := testClient.Ping(cancelled)
+		require.Error(t, err)
+	})
+}
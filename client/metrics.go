@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+	"time"
+)
+
+// BulkStateOperation names the bulk state operations reported to a MetricsObserver.
+type BulkStateOperation string
+
+const (
+	BulkStateGet    BulkStateOperation = "GetBulkState"
+	BulkStateSave   BulkStateOperation = "SaveBulkState"
+	BulkStateDelete BulkStateOperation = "DeleteBulkState"
+)
+
+// bulkSizeBuckets are the upper bounds of the size buckets of bulk state operations.
+var bulkSizeBuckets = []int{1, 10, 50, 100, 500, 1000}
+
+// BulkStateObservation is the timing of one bulk state operation.
+type BulkStateObservation struct {
+	Operation BulkStateOperation
+	StoreName string
+	// Size is the number of keys or items of the operation.
+	Size int
+	// SizeBucket is the upper bound of the bucket of Size, "1", "10", "50", "100", "500", "1000"
+	// or "+Inf", to be used as a histogram label.
+	SizeBucket string
+	Duration   time.Duration
+	// Err is the error returned by the operation, nil on success.
+	Err error
+}
+
+// MetricsObserver receives the measurements of the client, it is typically backed by
+// the histograms of a metrics library. Its methods are called synchronously by the
+// operations being measured, so they must be fast and safe for concurrent use.
+type MetricsObserver interface {
+	// ObserveBulkState is called after every GetBulkState, SaveBulkState and DeleteBulkState call,
+	// including the ones made by the helpers built on them such as SaveBulkStateChunked.
+	ObserveBulkState(o BulkStateObservation)
+}
+
+// WithMetricsObserver enables the metrics of the client, which are reported to observer.
+func WithMetricsObserver(observer MetricsObserver) ClientOption {
+	return func(o *clientOptions) {
+		o.metrics = observer
+	}
+}
+
+// bulkSizeBucket returns the label of the bucket of size.
+func bulkSizeBucket(size int) string {
+	for _, bound := range bulkSizeBuckets {
+		if size <= bound {
+			return strconv.Itoa(bound)
+		}
+	}
+	return "+Inf"
+}
+
+// observeBulkState reports a bulk state operation started at start to the metrics observer, if any.
+func (c *GRPCClient) observeBulkState(op BulkStateOperation, storeName string, size int, start time.Time, err error) {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.ObserveBulkState(BulkStateObservation{
+		Operation:  op,
+		StoreName:  storeName,
+		Size:       size,
+		SizeBucket: bulkSizeBucket(size),
+		Duration:   time.Since(start),
+		Err:        err,
+	})
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	mu           sync.Mutex
+	observations []BulkStateObservation
+}
+
+func (o *recordingObserver) ObserveBulkState(obs BulkStateObservation) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.observations = append(o.observations, obs)
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestBulkStateMetrics$
+func TestBulkStateMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("bulk operations are observed", func(t *testing.T) {
+		observer := &recordingObserver{}
+		c := &GRPCClient{protoClient: newEtagStateClient(), metrics: observer}
+		_, err := c.GetBulkState(ctx, testStore, []string{"from", "to"}, nil, 0)
+		require.NoError(t, err)
+
+		c.protoClient = &chunkRecordingClient{}
+		items := make([]*SetStateItem, 0, 20)
+		for i := 0; i < 20; i++ {
+			items = append(items, &SetStateItem{Key: "key" + strconv.Itoa(i), Value: []byte("v")})
+		}
+		require.NoError(t, c.SaveBulkState(ctx, testStore, items...))
+
+		require.Len(t, observer.observations, 2)
+		get, save := observer.observations[0], observer.observations[1]
+		assert.Equal(t, BulkStateGet, get.Operation)
+		assert.Equal(t, testStore, get.StoreName)
+		assert.Equal(t, 2, get.Size)
+		assert.Equal(t, "10", get.SizeBucket)
+		assert.NoError(t, get.Err)
+		assert.Positive(t, get.Duration)
+		assert.Equal(t, BulkStateSave, save.Operation)
+		assert.Equal(t, 20, save.Size)
+		assert.Equal(t, "50", save.SizeBucket)
+	})
+
+	t.Run("single item saves aren't observed", func(t *testing.T) {
+		observer := &recordingObserver{}
+		c := &GRPCClient{protoClient: &chunkRecordingClient{}, metrics: observer}
+		require.NoError(t, c.SaveState(ctx, testStore, "key1", []byte("v"), nil))
+		assert.Empty(t, observer.observations)
+	})
+
+	t.Run("failures are observed", func(t *testing.T) {
+		observer := &recordingObserver{}
+		c := &GRPCClient{protoClient: &chunkRecordingClient{failKey: "key1"}, metrics: observer}
+		require.Error(t, c.SaveBulkState(ctx, testStore, &SetStateItem{Key: "key1"}))
+		require.Len(t, observer.observations, 1)
+		assert.Error(t, observer.observations[0].Err)
+	})
+
+	t.Run("size buckets", func(t *testing.T) {
+		assert.Equal(t, "1", bulkSizeBucket(0))
+		assert.Equal(t, "1", bulkSizeBucket(1))
+		assert.Equal(t, "100", bulkSizeBucket(100))
+		assert.Equal(t, "500", bulkSizeBucket(101))
+		assert.Equal(t, "+Inf", bulkSizeBucket(1001))
+	})
+}
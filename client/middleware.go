@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/grpc"
+)
+
+// RequestMiddleware wraps the calls of the client to the Dapr API. methodName is the name of the
+// Dapr API method, such as "SaveState" or "InvokeService", and req its typed request from the Dapr
+// runtime protos, such as *SaveStateRequest, built by the client from the arguments of the public method.
+// Calling next sends req, which the middleware may modify beforehand, and returns the typed
+// response; a middleware may also return its own response of the same type, or an error,
+// without calling next.
+type RequestMiddleware func(ctx context.Context, methodName string, req any, next func() (any, error)) (any, error)
+
+// WithMiddleware registers middleware around every call to the Dapr API, the first registered
+// middleware being the outermost. Client-side streams, such as the ones of EncryptAlpha1 and
+// DecryptAlpha1, have no request and aren't wrapped.
+func WithMiddleware(mw ...RequestMiddleware) ClientOption {
+	return func(o *clientOptions) {
+		o.middleware = append(o.middleware, mw...)
+	}
+}
+
+// callDapr calls the Dapr API method through the middleware of c, with the call options carried by ctx
+// and the rate limit of c.
+func callDapr[Req, Resp any](ctx context.Context, c *GRPCClient, method string, req Req, call func(context.Context, Req, ...grpc.CallOption) (Resp, error)) (resp Resp, err error) {
+	if c.scope != nil {
+		// Streams outlive the request the scope is bound to, only the scope metadata applies to them.
+		if reflect.TypeOf((*Resp)(nil)).Elem().Implements(reflect.TypeOf((*grpc.ClientStream)(nil)).Elem()) {
+			ctx = c.scope.withMetadata(ctx)
+		} else {
+			var release context.CancelFunc
+			if ctx, release, err = c.scope.unary(ctx); err != nil {
+				return resp, err
+			}
+			defer release()
+		}
+	}
+	if c.rateLimiter != nil {
+		unlimited := call
+		call = func(ctx context.Context, req Req, opts ...grpc.CallOption) (Resp, error) {
+			if err := c.rateLimiter.wait(ctx); err != nil {
+				var zero Resp
+				return zero, err
+			}
+			return unlimited(ctx, req, opts...)
+		}
+	}
+	ctx, cancel, run := prepareCall(ctx, req, call)
+	defer func() {
+		// Streams outlive the call, their timeout is then released when it expires.
+		if _, ok := any(resp).(grpc.ClientStream); !ok || err != nil {
+			cancel()
+		}
+	}()
+	if len(c.middleware) == 0 {
+		return run()
+	}
+	next := func() (any, error) {
+		return run()
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		mw, inner := c.middleware[i], next
+		next = func() (any, error) {
+			return mw(ctx, method, req, inner)
+		}
+	}
+	out, err := next()
+	resp, ok := out.(Resp)
+	if !ok && out != nil {
+		var zero Resp
+		return zero, fmt.Errorf("middleware returned %T for %s, expected %T", out, method, zero)
+	}
+	return resp, err
+}
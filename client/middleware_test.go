@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// go test -timeout 30s ./client -count 1 -run ^TestMiddleware$
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("middleware observes and modifies the request", func(t *testing.T) {
+		fake := &chunkRecordingClient{}
+		var calls []string
+		record := func(name string) RequestMiddleware {
+			return func(ctx context.Context, methodName string, req any, next func() (any, error)) (any, error) {
+				calls = append(calls, name+":"+methodName)
+				return next()
+			}
+		}
+		rename := func(ctx context.Context, methodName string, req any, next func() (any, error)) (any, error) {
+			if r, ok := req.(*pb.SaveStateRequest); ok {
+				for _, item := range r.GetStates() {
+					item.Key = "tenant-" + item.GetKey()
+				}
+			}
+			return next()
+		}
+		c := &GRPCClient{protoClient: fake, middleware: []RequestMiddleware{record("outer"), rename, record("inner")}}
+		require.NoError(t, c.SaveState(ctx, testStore, "key1", []byte("v"), nil))
+		assert.Equal(t, []string{"outer:SaveState", "inner:SaveState"}, calls)
+		assert.Equal(t, [][]string{{"tenant-key1"}}, fake.requests)
+	})
+
+	t.Run("middleware can answer without calling the API", func(t *testing.T) {
+		answer := func(ctx context.Context, methodName string, req any, next func() (any, error)) (any, error) {
+			return &pb.GetStateResponse{Data: []byte("cached"), Etag: "1"}, nil
+		}
+		c := &GRPCClient{protoClient: &chunkRecordingClient{}, middleware: []RequestMiddleware{answer}}
+		item, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("cached"), item.Value)
+	})
+
+	t.Run("response of the wrong type", func(t *testing.T) {
+		wrong := func(ctx context.Context, methodName string, req any, next func() (any, error)) (any, error) {
+			return &emptypb.Empty{}, nil
+		}
+		c := &GRPCClient{protoClient: &chunkRecordingClient{}, middleware: []RequestMiddleware{wrong}}
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.ErrorContains(t, err, "middleware returned")
+	})
+}
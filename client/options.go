@@ -0,0 +1,337 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+
+	"github.com/dapr/go-sdk/client/internal"
+)
+
+// ErrDialTimeout is returned when the connection to the sidecar can't be established within the dial timeout.
+var ErrDialTimeout = errors.New("timed out connecting to the Dapr sidecar")
+
+// ClientOption configures a client created with NewClientWithOptions.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	address   string
+	port      string
+	socket    string
+	tlsConfig *tls.Config
+	insecure  bool
+	apiToken  string
+	timeout   time.Duration
+
+	traceStateKeys     traceKeysMode
+	stateKeyValidation bool
+	defaultStateStore  string
+	poolSize           int
+	stateCache         *CacheConfig
+	stateEncryption    *stateEncryption
+	middleware         []RequestMiddleware
+	metrics            MetricsObserver
+	rateLimit          *rateLimitConfig
+	reconnect          *reconnectConfig
+
+	errs []error
+}
+
+// WithAddress sets the address (including port) of the Dapr sidecar.
+// It accepts the same formats as the DAPR_GRPC_ENDPOINT environment variable.
+func WithAddress(address string) ClientOption {
+	return func(o *clientOptions) {
+		if address == "" {
+			o.errs = append(o.errs, errors.New("empty address"))
+		}
+		o.address = address
+	}
+}
+
+// WithPort sets the gRPC port of a Dapr sidecar running on localhost.
+func WithPort(port string) ClientOption {
+	return func(o *clientOptions) {
+		if port == "" {
+			o.errs = append(o.errs, errors.New("empty port"))
+		}
+		o.port = port
+	}
+}
+
+// WithSocket sets the path of the unix domain socket the Dapr sidecar listens on.
+func WithSocket(socket string) ClientOption {
+	return func(o *clientOptions) {
+		if socket == "" {
+			o.errs = append(o.errs, errors.New("empty socket"))
+		}
+		o.socket = socket
+	}
+}
+
+// WithTLSConfig enables TLS using the given configuration.
+// Add client certificates to the configuration to use mTLS.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(o *clientOptions) {
+		if config == nil {
+			o.errs = append(o.errs, errors.New("nil TLS config"))
+		}
+		o.tlsConfig = config
+	}
+}
+
+// WithInsecure explicitly disables transport security.
+func WithInsecure() ClientOption {
+	return func(o *clientOptions) {
+		o.insecure = true
+	}
+}
+
+// WithAPIToken sets the Dapr API token, overriding the DAPR_API_TOKEN environment variable.
+func WithAPIToken(token string) ClientOption {
+	return func(o *clientOptions) {
+		o.apiToken = token
+	}
+}
+
+// WithDialTimeout bounds the initial connection attempt to the sidecar, overriding the
+// DAPR_CLIENT_TIMEOUT_SECONDS environment variable. NewClientWithOptions returns an error wrapping
+// ErrDialTimeout when the sidecar isn't reachable in time.
+func WithDialTimeout(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		if d <= 0 {
+			o.errs = append(o.errs, fmt.Errorf("invalid dial timeout %s: must be positive", d))
+		}
+		o.timeout = d
+	}
+}
+
+// WithTraceStateKeys records the keys of state transactions as attributes on the trace span
+// found in the call context. Keys are not recorded by default since they can be sensitive,
+// when redact is true each key is replaced with a short hash of its value.
+func WithTraceStateKeys(redact bool) ClientOption {
+	return func(o *clientOptions) {
+		o.traceStateKeys = traceKeysRaw
+		if redact {
+			o.traceStateKeys = traceKeysRedacted
+		}
+	}
+}
+
+// WithStateKeyValidation rejects state keys containing separators reserved by the Dapr runtime,
+// such as "||", before any call is made to the sidecar.
+func WithStateKeyValidation() ClientOption {
+	return func(o *clientOptions) {
+		o.stateKeyValidation = true
+	}
+}
+
+// WithDefaultStateStore sets the state store used by the state methods called with an empty store
+// name: the SaveState, GetState and DeleteState families, the bulk methods, ExecuteStateTransaction,
+// WithTransaction and QueryStateAlpha1. An explicit store name always takes precedence.
+func WithDefaultStateStore(name string) ClientOption {
+	return func(o *clientOptions) {
+		if strings.TrimSpace(name) == "" {
+			o.errs = append(o.errs, errors.New("invalid default state store: name must not be empty"))
+		}
+		o.defaultStateStore = name
+	}
+}
+
+// WithConnectionPool opens size connections to the sidecar and distributes calls round-robin
+// across them, which avoids a single connection becoming a bottleneck under heavy concurrency.
+// Close waits up to 10s for in-flight unary calls before closing all connections of the pool.
+func WithConnectionPool(size int) ClientOption {
+	return func(o *clientOptions) {
+		if size < 1 {
+			o.errs = append(o.errs, fmt.Errorf("invalid connection pool size %d: must be at least 1", size))
+		}
+		o.poolSize = size
+	}
+}
+
+// NewClientWithOptions instantiates a Dapr client using the given options.
+// Conflicting options, such as an address together with a socket or TLS together with WithInsecure,
+// are reported before any connection is attempted.
+// When neither an address, port nor socket is given, the DAPR_GRPC_ENDPOINT and DAPR_GRPC_PORT
+// environment variables are used in that order, falling back to port 50001 on localhost.
+// The connection timeout is set with WithDialTimeout, it defaults to the DAPR_CLIENT_TIMEOUT_SECONDS
+// environment variable, or 5 seconds.
+func NewClientWithOptions(ctx context.Context, opts ...ClientOption) (Client, error) {
+	o := &clientOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if err := o.setDefaults(); err != nil {
+		return nil, err
+	}
+	return o.dial(ctx)
+}
+
+func (o *clientOptions) validate() error {
+	if len(o.errs) > 0 {
+		return fmt.Errorf("invalid client options: %w", errors.Join(o.errs...))
+	}
+	if o.address != "" && o.port != "" {
+		return errors.New("invalid client options: address and port are mutually exclusive, include the port in the address")
+	}
+	if o.socket != "" && (o.address != "" || o.port != "") {
+		return errors.New("invalid client options: socket cannot be combined with an address or port")
+	}
+	if o.socket != "" && o.tlsConfig != nil {
+		return errors.New("invalid client options: TLS is not supported over a unix domain socket")
+	}
+	if o.insecure && o.tlsConfig != nil {
+		return errors.New("invalid client options: insecure and TLS config are mutually exclusive")
+	}
+	if o.reconnect != nil && o.reconnect.interval == 0 {
+		return errors.New("invalid client options: reconnect threshold requires WithAutoReconnect")
+	}
+	return nil
+}
+
+func (o *clientOptions) setDefaults() error {
+	if o.address == "" && o.port == "" && o.socket == "" {
+		if addr, ok := os.LookupEnv(daprGRPCEndpointEnvVarName); ok {
+			o.address = addr
+		} else if port, ok := os.LookupEnv(daprPortEnvVarName); ok {
+			o.port = port
+		} else {
+			o.port = daprPortDefault
+		}
+	}
+	if o.port != "" {
+		o.address = net.JoinHostPort("127.0.0.1", o.port)
+	}
+	if o.timeout == 0 {
+		timeoutSeconds, err := getClientTimeoutSeconds()
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", clientTimeoutSecondsEnvVarName, err)
+		}
+		o.timeout = time.Duration(timeoutSeconds) * time.Second
+	}
+	return nil
+}
+
+// dialError wraps a failed dial to target, reporting ErrDialTimeout when the dial timeout
+// rather than the caller's context expired.
+func dialError(ctx context.Context, target string, timeout time.Duration, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("error creating connection to '%s': %w after %s: %w", target, ErrDialTimeout, timeout, err)
+	}
+	return fmt.Errorf("error creating connection to '%s': %w", target, err)
+}
+
+func (o *clientOptions) dial(ctx context.Context) (Client, error) {
+	target := "unix://" + o.socket
+	useTLS := false
+	if o.socket == "" {
+		parsedAddress, err := internal.ParseGRPCEndpoint(o.address)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing address '%s': %w", o.address, err)
+		}
+		if parsedAddress.TLS && o.insecure {
+			return nil, fmt.Errorf("invalid client options: address '%s' requires TLS but insecure was requested", o.address)
+		}
+		target = parsedAddress.Target
+		useTLS = parsedAddress.TLS
+	}
+	useTLS = useTLS || o.tlsConfig != nil
+	logger.Printf("dapr client initializing for: %s", target)
+
+	at := &authToken{}
+	dialOpts := []grpc.DialOption{
+		grpc.WithUserAgent(userAgent()),
+		grpc.WithBlock(),
+		authTokenUnaryInterceptor(at),
+		authTokenStreamInterceptor(at),
+	}
+	if useTLS {
+		config := o.tlsConfig
+		if config == nil {
+			config = new(tls.Config)
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	size := max(o.poolSize, 1)
+	dialConns := func(ctx context.Context) ([]*grpc.ClientConn, error) {
+		dialCtx, cancel := context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+		conns := make([]*grpc.ClientConn, 0, size)
+		for i := 0; i < size; i++ {
+			conn, err := grpc.DialContext(dialCtx, target, dialOpts...)
+			if err != nil {
+				for _, c := range conns {
+					c.Close()
+				}
+				return nil, dialError(ctx, target, o.timeout, err)
+			}
+			conns = append(conns, conn)
+		}
+		return conns, nil
+	}
+	conns, err := dialConns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client := newClientWithConnection(conns[0], at)
+	// Reconnecting swaps the connections of a pool, so a pool is used even for a single connection.
+	if size > 1 || o.reconnect != nil {
+		client.pool = newConnPool(conns)
+		client.protoClient = pb.NewDaprClient(client.pool)
+	}
+	if o.apiToken != "" {
+		at.set(o.apiToken)
+	}
+	client.traceStateKeys = o.traceStateKeys
+	client.stateKeyValidation = o.stateKeyValidation
+	client.defaultStateStore = o.defaultStateStore
+	client.stateEncryption = o.stateEncryption
+	client.middleware = o.middleware
+	client.metrics = o.metrics
+	if o.rateLimit != nil {
+		client.rateLimiter = newRateLimiter(*o.rateLimit)
+	}
+	if o.reconnect != nil {
+		health := pb.NewDaprClient(client.pool)
+		client.monitor = newHealthMonitor(*o.reconnect, client.pool, func(ctx context.Context) error {
+			_, err := health.GetMetadata(ctx, &pb.GetMetadataRequest{})
+			return err
+		}, dialConns)
+		client.monitor.start()
+	}
+	if o.stateCache != nil {
+		client.stateCache = newStateCache(*o.stateCache)
+	}
+	return client, nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// startTestTCPServer starts a test Dapr server on a random localhost port and returns its port.
+func startTestTCPServer(t testing.TB) string {
+	t.Helper()
+	s := grpc.NewServer()
+	pb.RegisterDaprServer(s, &testDaprServer{
+		state:                       make(map[string][]byte),
+		configurationSubscriptionID: map[string]chan struct{}{},
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = s.Serve(l)
+	}()
+	t.Cleanup(s.Stop)
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+func TestNewClientWithOptionsValidation(t *testing.T) {
+	ctx := context.Background()
+	tests := map[string][]ClientOption{
+		"empty address":         {WithAddress("")},
+		"empty port":            {WithPort("")},
+		"empty socket":          {WithSocket("")},
+		"nil tls config":        {WithTLSConfig(nil)},
+		"address and port":      {WithAddress("localhost:50001"), WithPort("50001")},
+		"socket and address":    {WithSocket(testSocket), WithAddress("localhost:50001")},
+		"socket and port":       {WithSocket(testSocket), WithPort("50001")},
+		"socket and tls":        {WithSocket(testSocket), WithTLSConfig(&tls.Config{})},
+		"insecure and tls":      {WithInsecure(), WithTLSConfig(&tls.Config{})},
+		"insecure and https":    {WithInsecure(), WithAddress("https://localhost:50001")},
+		"insecure and tls=true": {WithInsecure(), WithAddress("localhost:50001?tls=true")},
+		"invalid address":       {WithAddress("foo://localhost:50001")},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := NewClientWithOptions(ctx, opts...)
+			require.Error(t, err)
+			assert.Nil(t, c)
+		})
+	}
+
+	t.Run("invalid dial timeout", func(t *testing.T) {
+		_, err := NewClientWithOptions(ctx, WithPort("50001"), WithDialTimeout(0))
+		require.Error(t, err)
+	})
+
+	t.Run("empty default state store", func(t *testing.T) {
+		_, err := NewClientWithOptions(ctx, WithPort("50001"), WithDefaultStateStore(""))
+		require.Error(t, err)
+	})
+
+	t.Run("dial timeout against unreachable address", func(t *testing.T) {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := lis.Addr().String()
+		require.NoError(t, lis.Close())
+
+		start := time.Now()
+		c, err := NewClientWithOptions(ctx, WithAddress(addr), WithDialTimeout(100*time.Millisecond))
+		require.ErrorIs(t, err, ErrDialTimeout)
+		assert.Nil(t, c)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("cancelled context is not a dial timeout", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := NewClientWithOptions(cancelled, WithAddress("127.0.0.1:1"), WithDialTimeout(time.Minute))
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrDialTimeout)
+	})
+
+	t.Run("invalid timeout env var", func(t *testing.T) {
+		t.Setenv(clientTimeoutSecondsEnvVarName, "invalid")
+		_, err := NewClientWithOptions(ctx, WithPort("50001"))
+		require.Error(t, err)
+	})
+}
+
+func TestNewClientWithOptionsDefaults(t *testing.T) {
+	t.Run("port from env var", func(t *testing.T) {
+		t.Setenv(daprPortEnvVarName, "1234")
+		o := &clientOptions{}
+		require.NoError(t, o.setDefaults())
+		assert.Equal(t, "127.0.0.1:1234", o.address)
+		assert.Equal(t, clientDefaultTimeoutSeconds, int(o.timeout.Seconds()))
+	})
+
+	t.Run("endpoint env var wins over port env var", func(t *testing.T) {
+		t.Setenv(daprGRPCEndpointEnvVarName, "https://example.com:443")
+		t.Setenv(daprPortEnvVarName, "1234")
+		o := &clientOptions{}
+		require.NoError(t, o.setDefaults())
+		assert.Equal(t, "https://example.com:443", o.address)
+	})
+
+	t.Run("explicit socket ignores env vars", func(t *testing.T) {
+		t.Setenv(daprPortEnvVarName, "1234")
+		o := &clientOptions{}
+		WithSocket(testSocket)(o)
+		require.NoError(t, o.setDefaults())
+		assert.Empty(t, o.address)
+	})
+}
+
+func TestNewClientWithOptions(t *testing.T) {
+	port := startTestTCPServer(t)
+
+	t.Run("connect with port", func(t *testing.T) {
+		c, err := NewClientWithOptions(context.Background(), WithPort(port), WithInsecure(), WithAPIToken("token"))
+		require.NoError(t, err)
+		defer c.Close()
+		require.NoError(t, c.Shutdown(context.Background()))
+		assert.Equal(t, "token", c.(*GRPCClient).authToken.get())
+	})
+
+	t.Run("connect with address", func(t *testing.T) {
+		c, err := NewClientWithOptions(context.Background(), WithAddress("localhost:"+port), WithStateKeyValidation())
+		require.NoError(t, err)
+		defer c.Close()
+		require.NoError(t, c.Shutdown(context.Background()))
+		assert.True(t, c.(*GRPCClient).stateKeyValidation)
+	})
+
+	t.Run("connect with auto reconnect", func(t *testing.T) {
+		c, err := NewClientWithOptions(context.Background(), WithPort(port), WithAutoReconnect(10*time.Millisecond))
+		require.NoError(t, err)
+		defer c.Close()
+		require.NotNil(t, c.(*GRPCClient).monitor)
+		require.NotNil(t, c.(*GRPCClient).pool)
+		// Let the monitor run a few health checks against the sidecar.
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, c.Shutdown(context.Background()))
+	})
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// poolDrainTimeout bounds how long close and reset wait for the in-flight unary calls of
+// the connections they tear down.
+const poolDrainTimeout = 10 * time.Second
+
+// errPoolClosed is returned by the calls started once the pool is closed, like the calls
+// of a closed grpc.ClientConn.
+var errPoolClosed = status.Error(codes.Canceled, "connection pool is closed")
+
+// connPool is a grpc.ClientConnInterface which distributes calls round-robin across several
+// connections to the sidecar.
+type connPool struct {
+	next         atomic.Uint64
+	drainTimeout time.Duration
+
+	// mu guards conns and closed, it is only held to pick a connection or to swap them, never during a call.
+	mu     sync.RWMutex
+	conns  *poolConns
+	closed bool
+}
+
+// poolConns is a set of connections of a pool, with its in-flight unary calls.
+type poolConns struct {
+	conns []*grpc.ClientConn
+	calls sync.WaitGroup
+}
+
+func newConnPool(conns []*grpc.ClientConn) *connPool {
+	return &connPool{conns: &poolConns{conns: conns}, drainTimeout: poolDrainTimeout}
+}
+
+// connections returns the current connections of the pool.
+func (p *connPool) connections() []*grpc.ClientConn {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.conns.conns
+}
+
+// currentConn returns the first of the current connections of the pool.
+func (p *connPool) currentConn() *grpc.ClientConn {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.conns.conns[0]
+}
+
+// acquire picks the next connection and, when track is true, counts the call as in flight
+// until release is called. Once the pool is closed it returns errPoolClosed, the call is then
+// never counted while close waits for the calls in flight.
+func (p *connPool) acquire(track bool) (*grpc.ClientConn, func(), error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, nil, errPoolClosed
+	}
+	set := p.conns
+	conn := set.conns[(p.next.Add(1)-1)%uint64(len(set.conns))]
+	if !track {
+		return conn, func() {}, nil
+	}
+	set.calls.Add(1)
+	return conn, set.calls.Done, nil
+}
+
+func (p *connPool) pick() *grpc.ClientConn {
+	conn, _, _ := p.acquire(false)
+	return conn
+}
+
+// Invoke performs a unary call on the next connection of the pool.
+func (p *connPool) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	conn, release, err := p.acquire(true)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return conn.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream begins a streaming call on the next connection of the pool.
+func (p *connPool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	conn, _, err := p.acquire(false)
+	if err != nil {
+		return nil, err
+	}
+	return conn.NewStream(ctx, desc, method, opts...)
+}
+
+// reset replaces the connections of the pool with conns, new calls then use conns right away.
+// The previous connections are closed once their in-flight unary calls completed, or once the
+// drain timeout elapsed, which cancels the calls still in flight. Open streams on the previous
+// connections are terminated. Once the pool is closed, conns are closed right away.
+func (p *connPool) reset(conns []*grpc.ClientConn) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return p.drain(&poolConns{conns: conns})
+	}
+	previous := p.conns
+	p.conns = &poolConns{conns: conns}
+	p.mu.Unlock()
+	return p.drain(previous)
+}
+
+// close waits for in-flight unary calls to complete, at most for the drain timeout, and closes
+// all connections of the pool. Open streams are terminated, and calls starting afterwards fail.
+func (p *connPool) close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	current := p.conns
+	p.mu.Unlock()
+	return p.drain(current)
+}
+
+func (p *connPool) drain(set *poolConns) error {
+	drained := make(chan struct{})
+	go func() {
+		set.calls.Wait()
+		close(drained)
+	}()
+	timer := time.NewTimer(p.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+	case <-timer.C:
+	}
+	errs := make([]error, 0)
+	for _, conn := range set.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
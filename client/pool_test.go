@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// go test -timeout 30s ./client -count 1 -run ^TestConnectionPool$
+func TestConnectionPool(t *testing.T) {
+	ctx := context.Background()
+	port := startTestTCPServer(t)
+
+	t.Run("invalid size", func(t *testing.T) {
+		_, err := NewClientWithOptions(ctx, WithPort(port), WithConnectionPool(0))
+		require.Error(t, err)
+	})
+
+	t.Run("calls are distributed across connections", func(t *testing.T) {
+		c, err := NewClientWithOptions(ctx, WithPort(port), WithConnectionPool(3))
+		require.NoError(t, err)
+		pool := c.(*GRPCClient).pool
+		require.NotNil(t, pool)
+		require.Len(t, pool.connections(), 3)
+
+		used := make(map[*grpc.ClientConn]int)
+		for i := 0; i < 6; i++ {
+			used[pool.pick()]++
+		}
+		for _, conn := range pool.connections() {
+			assert.Equal(t, 2, used[conn])
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 9; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.GetState(ctx, testStore, "pool-key", nil)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		c.Close()
+		for _, conn := range pool.connections() {
+			assert.Equal(t, connectivity.Shutdown, conn.GetState())
+		}
+	})
+}
+
+// poolTestServer answers every unary call with an empty message and records the listener the call
+// was received on. While hold is set, calls wait until release is closed.
+type poolTestServer struct {
+	mu       sync.Mutex
+	received []int
+	hold     bool
+	started  chan struct{}
+	release  chan struct{}
+}
+
+func (s *poolTestServer) listen(t *testing.T, index int) *grpc.ClientConn {
+	lis := bufconn.Listen(testBufSize)
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(srv any, stream grpc.ServerStream) error {
+		if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.received = append(s.received, index)
+		hold := s.hold
+		s.mu.Unlock()
+		if hold {
+			s.started <- struct{}{}
+			<-s.release
+		}
+		return stream.SendMsg(&emptypb.Empty{})
+	}))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	return conn
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestConnectionPoolCalls$
+func TestConnectionPoolCalls(t *testing.T) {
+	ctx := context.Background()
+	call := func(pool *connPool) error {
+		return pool.Invoke(ctx, "/test.Pool/Call", &emptypb.Empty{}, &emptypb.Empty{})
+	}
+
+	t.Run("calls alternate across connections", func(t *testing.T) {
+		server := &poolTestServer{}
+		pool := newConnPool([]*grpc.ClientConn{server.listen(t, 0), server.listen(t, 1)})
+		defer pool.close()
+		for i := 0; i < 4; i++ {
+			require.NoError(t, call(pool))
+		}
+		assert.Equal(t, []int{0, 1, 0, 1}, server.received)
+	})
+
+	t.Run("close waits for an in-flight call", func(t *testing.T) {
+		server := &poolTestServer{hold: true, started: make(chan struct{}, 1), release: make(chan struct{})}
+		pool := newConnPool([]*grpc.ClientConn{server.listen(t, 0), server.listen(t, 1)})
+		called := make(chan error, 1)
+		go func() { called <- call(pool) }()
+		<-server.started
+
+		closed := make(chan error, 1)
+		go func() { closed <- pool.close() }()
+		select {
+		case <-closed:
+			t.Fatal("close didn't wait for the in-flight call")
+		case <-time.After(20 * time.Millisecond):
+		}
+		close(server.release)
+		require.NoError(t, <-called)
+		require.NoError(t, <-closed)
+		for _, conn := range pool.connections() {
+			assert.Equal(t, connectivity.Shutdown, conn.GetState())
+		}
+	})
+
+	t.Run("calls starting after close fail", func(t *testing.T) {
+		server := &poolTestServer{hold: true, started: make(chan struct{}, 1), release: make(chan struct{})}
+		pool := newConnPool([]*grpc.ClientConn{server.listen(t, 0)})
+		called := make(chan error, 1)
+		go func() { called <- call(pool) }()
+		<-server.started
+
+		closed := make(chan error, 1)
+		go func() { closed <- pool.close() }()
+		require.Eventually(t, func() bool {
+			pool.mu.RLock()
+			defer pool.mu.RUnlock()
+			return pool.closed
+		}, 5*time.Second, time.Millisecond)
+		require.ErrorIs(t, call(pool), errPoolClosed)
+		_, err := pool.NewStream(ctx, &grpc.StreamDesc{}, "/test.Pool/Stream")
+		require.ErrorIs(t, err, errPoolClosed)
+		assert.Equal(t, codes.Canceled, status.Code(err))
+
+		close(server.release)
+		require.NoError(t, <-called)
+		require.NoError(t, <-closed)
+		assert.Equal(t, []int{0}, server.received)
+		require.NoError(t, pool.close())
+	})
+}
+
+// go test ./client -run ^$ -bench ^BenchmarkConnectionPool$
+func BenchmarkConnectionPool(b *testing.B) {
+	ctx := context.Background()
+	port := startTestTCPServer(b)
+	for _, size := range []int{1, 4} {
+		c, err := NewClientWithOptions(ctx, WithPort(port), WithConnectionPool(size))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run("size-"+strconv.Itoa(size), func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := c.GetState(ctx, testStore, "bench-key", nil); err != nil {
+						b.Error(err)
+					}
+				}
+			})
+		})
+		c.Close()
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/google/uuid"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/service/common"
 )
 
 const (
@@ -36,7 +37,7 @@ type PublishEventOption func(*pb.PublishEventRequest)
 // PublishEvent publishes data onto specific pubsub topic.
 func (c *GRPCClient) PublishEvent(ctx context.Context, pubsubName, topicName string, data interface{}, opts ...PublishEventOption) error {
 	if pubsubName == "" {
-		return errors.New("pubsubName name required")
+		return missingComponentName("pub/sub")
 	}
 	if topicName == "" {
 		return errors.New("topic name required")
@@ -66,7 +67,7 @@ func (c *GRPCClient) PublishEvent(ctx context.Context, pubsubName, topicName str
 		}
 	}
 
-	_, err := c.protoClient.PublishEvent(ctx, request)
+	_, err := callDapr(ctx, c, "PublishEvent", request, c.protoClient.PublishEvent)
 	if err != nil {
 		return fmt.Errorf("error publishing event unto %s topic: %w", topicName, err)
 	}
@@ -74,6 +75,37 @@ func (c *GRPCClient) PublishEvent(ctx context.Context, pubsubName, topicName str
 	return nil
 }
 
+// ForwardMessage republishes a received topic event onto another pubsub topic.
+// The original data, data content type and custom metadata (the `metadata.` prefixed
+// entries surfaced in TopicEvent.Metadata) are copied to the new message.
+// CloudEvent envelope attributes (ID, source, type, subject and trace context) are
+// dropped, the runtime generates a new envelope for the forwarded message.
+// Options are applied after the copied values and so can override them.
+func (c *GRPCClient) ForwardMessage(ctx context.Context, e *common.TopicEvent, pubsubName, topicName string, opts ...PublishEventOption) error {
+	if e == nil {
+		return errors.New("topic event required")
+	}
+
+	fwdOpts := make([]PublishEventOption, 0, len(opts)+2)
+	if e.DataContentType != "" {
+		fwdOpts = append(fwdOpts, PublishEventWithContentType(e.DataContentType))
+	}
+	if len(e.Metadata) > 0 {
+		meta := make(map[string]string, len(e.Metadata))
+		for k, v := range e.Metadata {
+			meta[k] = v
+		}
+		fwdOpts = append(fwdOpts, PublishEventWithMetadata(meta))
+	}
+	fwdOpts = append(fwdOpts, opts...)
+
+	var data interface{} = e.RawData
+	if len(e.RawData) == 0 {
+		data = e.Data
+	}
+	return c.PublishEvent(ctx, pubsubName, topicName, data, fwdOpts...)
+}
+
 // PublishEventWithContentType can be passed as option to PublishEvent to set an explicit Content-Type.
 func PublishEventWithContentType(contentType string) PublishEventOption {
 	return func(e *pb.PublishEventRequest) {
@@ -136,7 +168,7 @@ type PublishEventsOption func(*pb.BulkPublishRequest)
 func (c *GRPCClient) PublishEvents(ctx context.Context, pubsubName, topicName string, events []interface{}, opts ...PublishEventsOption) PublishEventsResponse {
 	if pubsubName == "" {
 		return PublishEventsResponse{
-			Error:        errors.New("pubsubName name required"),
+			Error:        missingComponentName("pub/sub"),
 			FailedEvents: events,
 		}
 	}
@@ -169,7 +201,7 @@ func (c *GRPCClient) PublishEvents(ctx context.Context, pubsubName, topicName st
 		o(request)
 	}
 
-	res, err := c.protoClient.BulkPublishEventAlpha1(ctx, request)
+	res, err := callDapr(ctx, c, "BulkPublishEventAlpha1", request, c.protoClient.BulkPublishEventAlpha1)
 	// If there is an error, all events failed to publish.
 	if err != nil {
 		return PublishEventsResponse{
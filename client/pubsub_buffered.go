@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	bufferMaxEventsDefault = 100
+	bufferMaxBytesDefault  = 1024 * 1024
+)
+
+// ErrBufferedPublisherClosed is returned when adding events to a closed BufferedPublisher.
+var ErrBufferedPublisherClosed = errors.New("buffered publisher is closed")
+
+// BufferedPublishError is returned when a flush of a BufferedPublisher fails.
+// FailedEvents holds the events which weren't published, they are not retried.
+type BufferedPublishError struct {
+	Err          error
+	FailedEvents [][]byte
+}
+
+func (e *BufferedPublishError) Error() string {
+	return fmt.Sprintf("error flushing %d buffered events: %v", len(e.FailedEvents), e.Err)
+}
+
+func (e *BufferedPublishError) Unwrap() error {
+	return e.Err
+}
+
+// BufferedPublisherOption configures a BufferedPublisher.
+type BufferedPublisherOption func(*BufferedPublisher)
+
+// WithBufferMaxEvents flushes the buffer once it holds n events, defaults to 100.
+func WithBufferMaxEvents(n int) BufferedPublisherOption {
+	return func(p *BufferedPublisher) {
+		p.maxEvents = n
+	}
+}
+
+// WithBufferMaxBytes flushes the buffer once the buffered event data totals n bytes, defaults to 1 MiB.
+func WithBufferMaxBytes(n int) BufferedPublisherOption {
+	return func(p *BufferedPublisher) {
+		p.maxBytes = n
+	}
+}
+
+// WithBufferPublishOptions sets the options passed to PublishEvents on each flush.
+func WithBufferPublishOptions(opts ...PublishEventsOption) BufferedPublisherOption {
+	return func(p *BufferedPublisher) {
+		p.publishOpts = opts
+	}
+}
+
+// BufferedPublisher buffers events for a single topic and publishes them with PublishEvents
+// once the buffered event count or size reaches the configured thresholds.
+// It is safe for concurrent use. Events are added while a flush is in flight, so the batches
+// of concurrent flushes may be published out of order.
+type BufferedPublisher struct {
+	client      Client
+	pubsubName  string
+	topicName   string
+	maxEvents   int
+	maxBytes    int
+	publishOpts []PublishEventsOption
+
+	mu     sync.Mutex
+	events [][]byte
+	size   int
+	closed bool
+}
+
+// NewBufferedPublisher returns a BufferedPublisher publishing onto topicName in pubsubName.
+func NewBufferedPublisher(c Client, pubsubName, topicName string, opts ...BufferedPublisherOption) *BufferedPublisher {
+	p := &BufferedPublisher{
+		client:     c,
+		pubsubName: pubsubName,
+		topicName:  topicName,
+		maxEvents:  bufferMaxEventsDefault,
+		maxBytes:   bufferMaxBytesDefault,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Add buffers a copy of data, flushing the buffer when a threshold is reached.
+// The error of a flush triggered by Add is returned as a *BufferedPublishError.
+func (p *BufferedPublisher) Add(ctx context.Context, data []byte) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrBufferedPublisherClosed
+	}
+	p.events = append(p.events, bytes.Clone(data))
+	p.size += len(data)
+	var events [][]byte
+	if len(p.events) >= p.maxEvents || p.size >= p.maxBytes {
+		events = p.takeLocked()
+	}
+	p.mu.Unlock()
+	return p.publish(ctx, events)
+}
+
+// Flush publishes all buffered events.
+func (p *BufferedPublisher) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	events := p.takeLocked()
+	p.mu.Unlock()
+	return p.publish(ctx, events)
+}
+
+// Close flushes the remaining buffered events, further calls to Add fail.
+func (p *BufferedPublisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	events := p.takeLocked()
+	p.mu.Unlock()
+	return p.publish(ctx, events)
+}
+
+// takeLocked empties the buffer and returns its events, p.mu must be held.
+func (p *BufferedPublisher) takeLocked() [][]byte {
+	events := p.events
+	p.events = nil
+	p.size = 0
+	return events
+}
+
+// publish publishes events outside of the lock, so Add isn't blocked by a flush in flight.
+func (p *BufferedPublisher) publish(ctx context.Context, buffered [][]byte) error {
+	if len(buffered) == 0 {
+		return nil
+	}
+	events := make([]interface{}, len(buffered))
+	for i, e := range buffered {
+		events[i] = e
+	}
+
+	res := p.client.PublishEvents(ctx, p.pubsubName, p.topicName, events, p.publishOpts...)
+	if res.Error == nil {
+		return nil
+	}
+	failed := make([][]byte, 0, len(res.FailedEvents))
+	for _, e := range res.FailedEvents {
+		if b, ok := e.([]byte); ok {
+			failed = append(failed, b)
+		}
+	}
+	return &BufferedPublishError{Err: res.Error, FailedEvents: failed}
+}
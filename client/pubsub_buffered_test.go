@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+type captureBulkPublishClient struct {
+	pb.DaprClient
+	batches [][]*pb.BulkPublishRequestEntry
+	err     error
+}
+
+func (c *captureBulkPublishClient) BulkPublishEventAlpha1(ctx context.Context, in *pb.BulkPublishRequest, opts ...grpc.CallOption) (*pb.BulkPublishResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.batches = append(c.batches, in.GetEntries())
+	return &pb.BulkPublishResponse{}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestBufferedPublisher$
+func TestBufferedPublisher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("flush on event count", func(t *testing.T) {
+		proto := &captureBulkPublishClient{}
+		p := NewBufferedPublisher(&GRPCClient{protoClient: proto}, "messages", "test", WithBufferMaxEvents(2))
+		for _, e := range []string{"a", "b", "c"} {
+			require.NoError(t, p.Add(ctx, []byte(e)))
+		}
+		require.Len(t, proto.batches, 1)
+		assert.Len(t, proto.batches[0], 2)
+
+		require.NoError(t, p.Close(ctx))
+		require.Len(t, proto.batches, 2)
+		assert.Equal(t, []byte("c"), proto.batches[1][0].GetEvent())
+		require.ErrorIs(t, p.Add(ctx, []byte("d")), ErrBufferedPublisherClosed)
+	})
+
+	t.Run("flush on byte size", func(t *testing.T) {
+		proto := &captureBulkPublishClient{}
+		p := NewBufferedPublisher(&GRPCClient{protoClient: proto}, "messages", "test", WithBufferMaxBytes(10))
+		require.NoError(t, p.Add(ctx, []byte("12345")))
+		assert.Empty(t, proto.batches)
+		require.NoError(t, p.Add(ctx, []byte("678901")))
+		require.Len(t, proto.batches, 1)
+		assert.Len(t, proto.batches[0], 2)
+	})
+
+	t.Run("data is copied on add", func(t *testing.T) {
+		proto := &captureBulkPublishClient{}
+		p := NewBufferedPublisher(&GRPCClient{protoClient: proto}, "messages", "test")
+		data := []byte("abc")
+		require.NoError(t, p.Add(ctx, data))
+		copy(data, "xyz")
+		require.NoError(t, p.Flush(ctx))
+		require.Len(t, proto.batches, 1)
+		assert.Equal(t, []byte("abc"), proto.batches[0][0].GetEvent())
+	})
+
+	t.Run("flush error returns failed events", func(t *testing.T) {
+		proto := &captureBulkPublishClient{err: errors.New("unavailable")}
+		p := NewBufferedPublisher(&GRPCClient{protoClient: proto}, "messages", "test", WithBufferMaxEvents(1))
+		err := p.Add(ctx, []byte("a"))
+		var bpErr *BufferedPublishError
+		require.ErrorAs(t, err, &bpErr)
+		assert.Equal(t, [][]byte{[]byte("a")}, bpErr.FailedEvents)
+		require.NoError(t, p.Flush(ctx))
+	})
+}
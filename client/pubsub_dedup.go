@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+const (
+	dedupTTLDefault       = 24 * time.Hour
+	dedupKeyPrefixDefault = "dedup-"
+)
+
+// DedupOption configures the handler returned by DedupSubscribe.
+type DedupOption func(*dedupOptions)
+
+type dedupOptions struct {
+	ttl       time.Duration
+	keyPrefix string
+}
+
+// WithDedupTTL sets how long processed message IDs are remembered, defaults to 24 hours.
+// It must be at least 1 second, the state store must support TTLs for the records to expire.
+func WithDedupTTL(ttl time.Duration) DedupOption {
+	return func(o *dedupOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithDedupKeyPrefix sets the prefix of the state keys processed message IDs are recorded under,
+// defaults to "dedup-". The keys are made of the prefix and the CompositeKey of the pubsub name,
+// the topic and the ID.
+func WithDedupKeyPrefix(prefix string) DedupOption {
+	return func(o *dedupOptions) {
+		o.keyPrefix = prefix
+	}
+}
+
+// DedupSubscribe wraps a topic event handler so that messages delivered more than once are only
+// handled once. Before invoking the handler the ID of the event is looked up in the state store,
+// duplicates are acknowledged without invoking it. The ID is recorded once the handler succeeds.
+// IDs are scoped to the pubsub and topic of the event, events without an ID are always handled.
+// Register the returned handler with AddTopicEventHandler.
+func DedupSubscribe(c Client, storeName string, handler common.TopicEventHandler, opts ...DedupOption) (common.TopicEventHandler, error) {
+	o := &dedupOptions{
+		ttl:       dedupTTLDefault,
+		keyPrefix: dedupKeyPrefixDefault,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.ttl < time.Second {
+		return nil, fmt.Errorf("invalid dedup TTL %s: must be at least 1s", o.ttl)
+	}
+	meta := map[string]string{
+		metadataKeyTTLInSeconds: strconv.FormatInt(int64(o.ttl.Seconds()), 10),
+	}
+
+	return func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+		if e.ID == "" {
+			return handler(ctx, e)
+		}
+		key := o.keyPrefix + CompositeKey{parts: []string{e.PubsubName, e.Topic, e.ID}}.String()
+		item, err := c.GetState(ctx, storeName, key, nil)
+		if err != nil {
+			return true, fmt.Errorf("error checking message %s for duplicates: %w", e.ID, err)
+		}
+		if len(item.Value) > 0 {
+			return false, nil
+		}
+
+		if retry, err := handler(ctx, e); err != nil {
+			return retry, err
+		}
+		// the message was handled, failing it now would cause the duplicate delivery this prevents
+		if err := c.SaveState(ctx, storeName, key, []byte(time.Now().UTC().Format(time.RFC3339)), meta); err != nil {
+			logger.Printf("error recording processed message %s: %v", e.ID, err)
+		}
+		return false, nil
+	}, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/go-sdk/service/common"
+)
+
+func TestDedupSubscribe(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	fail := false
+	handler := func(ctx context.Context, e *common.TopicEvent) (bool, error) {
+		calls++
+		if fail {
+			return true, errors.New("handler failed")
+		}
+		return false, nil
+	}
+	h, err := DedupSubscribe(testClient, testStore, handler, WithDedupKeyPrefix("test-dedup-"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		for _, key := range []string{"messages:test:msg-1", "messages:other:msg-1", "messages:test:msg-2"} {
+			require.NoError(t, testClient.DeleteState(ctx, testStore, "test-dedup-"+key, nil))
+		}
+	})
+
+	t.Run("duplicate message is handled once", func(t *testing.T) {
+		calls = 0
+		e := &common.TopicEvent{ID: "msg-1", PubsubName: "messages", Topic: "test"}
+		for i := 0; i < 2; i++ {
+			retry, err := h(ctx, e)
+			require.NoError(t, err)
+			assert.False(t, retry)
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("ids are scoped to the topic", func(t *testing.T) {
+		calls = 0
+		_, err := h(ctx, &common.TopicEvent{ID: "msg-1", PubsubName: "messages", Topic: "other"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("failed message is not recorded", func(t *testing.T) {
+		calls = 0
+		fail = true
+		e := &common.TopicEvent{ID: "msg-2", PubsubName: "messages", Topic: "test"}
+		retry, err := h(ctx, e)
+		require.Error(t, err)
+		assert.True(t, retry)
+
+		fail = false
+		_, err = h(ctx, e)
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("message without id is always handled", func(t *testing.T) {
+		calls = 0
+		e := &common.TopicEvent{Topic: "test"}
+		for i := 0; i < 2; i++ {
+			_, err := h(ctx, e)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("keys pass state key validation", func(t *testing.T) {
+		validating := *testClient.(*GRPCClient)
+		validating.stateKeyValidation = true
+		h, err := DedupSubscribe(&validating, testStore, handler, WithDedupKeyPrefix("test-dedup-"))
+		require.NoError(t, err)
+		calls = 0
+		e := &common.TopicEvent{ID: "msg-3", PubsubName: "messages", Topic: "test"}
+		t.Cleanup(func() {
+			require.NoError(t, testClient.DeleteState(ctx, testStore, "test-dedup-messages:test:msg-3", nil))
+		})
+		for i := 0; i < 2; i++ {
+			retry, err := h(ctx, e)
+			require.NoError(t, err)
+			assert.False(t, retry)
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("ttl under a second is rejected", func(t *testing.T) {
+		_, err := DedupSubscribe(testClient, testStore, handler, WithDedupTTL(500*time.Millisecond))
+		require.Error(t, err)
+	})
+}
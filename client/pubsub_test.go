@@ -21,6 +21,11 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/go-sdk/service/common"
 )
 
 type _testCustomContentwithText struct {
@@ -330,3 +335,57 @@ func TestCreateBulkPublishRequestEntry(t *testing.T) {
 		}
 	})
 }
+
+type capturePublishClient struct {
+	pb.DaprClient
+	req *pb.PublishEventRequest
+}
+
+func (c *capturePublishClient) PublishEvent(ctx context.Context, in *pb.PublishEventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.req = in
+	return &emptypb.Empty{}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestForwardMessage$
+func TestForwardMessage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("nil event", func(t *testing.T) {
+		err := testClient.ForwardMessage(ctx, nil, "messages", "test")
+		require.Error(t, err)
+	})
+
+	t.Run("preserves data, content type and metadata", func(t *testing.T) {
+		proto := &capturePublishClient{}
+		c := &GRPCClient{protoClient: proto}
+		e := &common.TopicEvent{
+			ID:              "a123",
+			DataContentType: "application/xml",
+			RawData:         []byte("<ping/>"),
+			Data:            "<ping/>",
+			Topic:           "in",
+			PubsubName:      "messages",
+			Metadata:        map[string]string{"key1": "value1"},
+		}
+		err := c.ForwardMessage(ctx, e, "other", "out")
+		require.NoError(t, err)
+		require.NotNil(t, proto.req)
+		assert.Equal(t, "other", proto.req.GetPubsubName())
+		assert.Equal(t, "out", proto.req.GetTopic())
+		assert.Equal(t, "application/xml", proto.req.GetDataContentType())
+		assert.Equal(t, []byte("<ping/>"), proto.req.GetData())
+		assert.Equal(t, map[string]string{"key1": "value1"}, proto.req.GetMetadata())
+	})
+
+	t.Run("options override copied values", func(t *testing.T) {
+		proto := &capturePublishClient{}
+		c := &GRPCClient{protoClient: proto}
+		e := &common.TopicEvent{
+			DataContentType: "text/plain",
+			RawData:         []byte("ping"),
+		}
+		err := c.ForwardMessage(ctx, e, "other", "out", PublishEventWithContentType("application/octet-stream"))
+		require.NoError(t, err)
+		assert.Equal(t, "application/octet-stream", proto.req.GetDataContentType())
+	})
+}
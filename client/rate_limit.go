@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitStats reports the activity of the rate limiter of a client.
+type RateLimitStats struct {
+	// Allowed is the number of calls let through, immediately or after waiting.
+	Allowed uint64
+	// Delayed is the number of allowed calls which waited for a token.
+	Delayed uint64
+	// Cancelled is the number of calls whose context was done while waiting for a token.
+	Cancelled uint64
+	// Waited is the total time spent waiting for tokens.
+	Waited time.Duration
+	// Tokens is the number of tokens currently available, negative when calls are waiting.
+	Tokens float64
+}
+
+// WithRateLimit caps the calls of the client to the Dapr API at rps calls per second, with bursts
+// of up to burst calls. Calls over the limit block until a token is available, or fail with the
+// error of their context when it is done first. Retried attempts take a token each.
+func WithRateLimit(rps int, burst int) ClientOption {
+	return func(o *clientOptions) {
+		if rps < 1 {
+			o.errs = append(o.errs, fmt.Errorf("invalid rate limit %d: must be at least 1 call per second", rps))
+		}
+		if burst < 1 {
+			o.errs = append(o.errs, fmt.Errorf("invalid rate limit burst %d: must be at least 1", burst))
+		}
+		o.rateLimit = &rateLimitConfig{rps: rps, burst: burst}
+	}
+}
+
+type rateLimitConfig struct {
+	rps   int
+	burst int
+}
+
+// rateLimiter is a token bucket, calls reserve a token and wait until it is refilled.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	stats  RateLimitStats
+}
+
+func newRateLimiter(config rateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		rate:   float64(config.rps),
+		burst:  float64(config.burst),
+		tokens: float64(config.burst),
+		last:   time.Now(),
+	}
+}
+
+// refillLocked adds the tokens accumulated since the last refill.
+func (l *rateLimiter) refillLocked(now time.Time) {
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// wait blocks until a token is available or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	l.refillLocked(time.Now())
+	l.tokens--
+	if l.tokens >= 0 {
+		l.stats.Allowed++
+		l.mu.Unlock()
+		return nil
+	}
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		l.mu.Lock()
+		l.stats.Allowed++
+		l.stats.Delayed++
+		l.stats.Waited += delay
+		l.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		// Give the reserved token back to the calls waiting behind.
+		l.mu.Lock()
+		l.tokens++
+		l.stats.Cancelled++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// RateLimitStats returns the activity of the rate limiter set with WithRateLimit,
+// or zero stats when the client isn't rate limited.
+func (c *GRPCClient) RateLimitStats() RateLimitStats {
+	if c.rateLimiter == nil {
+		return RateLimitStats{}
+	}
+	l := c.rateLimiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refillLocked(time.Now())
+	stats := l.stats
+	stats.Tokens = l.tokens
+	return stats
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -timeout 30s ./client -count 1 -run ^TestRateLimit$
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("calls are paced", func(t *testing.T) {
+		fake := &storeRecordingClient{}
+		c := &GRPCClient{protoClient: fake, rateLimiter: newRateLimiter(rateLimitConfig{rps: 20, burst: 2})}
+		start := time.Now()
+		for i := 0; i < 6; i++ {
+			_, err := c.GetState(ctx, testStore, "key1", nil)
+			require.NoError(t, err)
+		}
+		// The burst lets 2 calls through, the 4 others are paced at 50ms.
+		elapsed := time.Since(start)
+		assert.GreaterOrEqual(t, elapsed, 180*time.Millisecond)
+		assert.Less(t, elapsed, 2*time.Second)
+		assert.Len(t, fake.stores, 6)
+
+		stats := c.RateLimitStats()
+		assert.Equal(t, uint64(6), stats.Allowed)
+		assert.Equal(t, uint64(4), stats.Delayed)
+		assert.Positive(t, stats.Waited)
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		fake := &storeRecordingClient{}
+		c := &GRPCClient{protoClient: fake, rateLimiter: newRateLimiter(rateLimitConfig{rps: 1, burst: 1})}
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = c.GetState(waitCtx, testStore, "key1", nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Len(t, fake.stores, 1)
+		assert.Equal(t, uint64(1), c.RateLimitStats().Cancelled)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &storeRecordingClient{}}
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, RateLimitStats{}, c.RateLimitStats())
+	})
+
+	t.Run("invalid limits", func(t *testing.T) {
+		_, err := NewClientWithOptions(ctx, WithPort("50001"), WithRateLimit(0, 1))
+		require.Error(t, err)
+		_, err = NewClientWithOptions(ctx, WithPort("50001"), WithRateLimit(10, 0))
+		require.Error(t, err)
+	})
+}
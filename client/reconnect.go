@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const reconnectThresholdDefault = 3
+
+type reconnectConfig struct {
+	interval  time.Duration
+	threshold int
+}
+
+// WithAutoReconnect checks the health of the sidecar every interval and resets the connections
+// to it once the checks failed as many consecutive times as set with WithReconnectThreshold,
+// 3 by default. New calls use the new connections right away, while the previous connections are
+// closed once their in-flight unary calls completed, cancelling the calls still running after 10s,
+// and terminating open streams. Health transitions and resets are logged with the
+// logger set with SetLogger.
+// The health checks call the GetMetadata API directly, bypassing the middleware and rate limit
+// of the client. GrpcClientConn, WatchConnectionState and Wait follow the connections replacing
+// the previous ones.
+func WithAutoReconnect(interval time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		if interval <= 0 {
+			o.errs = append(o.errs, fmt.Errorf("invalid reconnect interval %s: must be positive", interval))
+		}
+		if o.reconnect == nil {
+			o.reconnect = &reconnectConfig{threshold: reconnectThresholdDefault}
+		}
+		o.reconnect.interval = interval
+	}
+}
+
+// WithReconnectThreshold sets how many consecutive health checks must fail before
+// WithAutoReconnect resets the connections.
+func WithReconnectThreshold(failures int) ClientOption {
+	return func(o *clientOptions) {
+		if failures < 1 {
+			o.errs = append(o.errs, fmt.Errorf("invalid reconnect threshold %d: must be at least 1", failures))
+		}
+		if o.reconnect == nil {
+			o.reconnect = &reconnectConfig{}
+		}
+		o.reconnect.threshold = failures
+	}
+}
+
+// healthMonitor resets the connections of a pool when the sidecar stays unhealthy.
+type healthMonitor struct {
+	config reconnectConfig
+	pool   *connPool
+	check  func(ctx context.Context) error
+	redial func(ctx context.Context) ([]*grpc.ClientConn, error)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newHealthMonitor(config reconnectConfig, pool *connPool, check func(context.Context) error, redial func(context.Context) ([]*grpc.ClientConn, error)) *healthMonitor {
+	return &healthMonitor{
+		config: config,
+		pool:   pool,
+		check:  check,
+		redial: redial,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (m *healthMonitor) start() {
+	go m.run()
+}
+
+func (m *healthMonitor) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.config.interval)
+	defer ticker.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-m.stop
+		cancel()
+	}()
+
+	failures := 0
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+		checkCtx, checkCancel := context.WithTimeout(ctx, m.config.interval)
+		err := m.check(checkCtx)
+		checkCancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			if failures > 0 {
+				logger.Printf("dapr sidecar healthy again after %d failed health checks", failures)
+			}
+			failures = 0
+			continue
+		}
+		failures++
+		if failures == 1 {
+			logger.Printf("dapr sidecar unhealthy: %v", err)
+		}
+		if failures < m.config.threshold {
+			continue
+		}
+		logger.Printf("resetting connections to dapr sidecar after %d failed health checks", failures)
+		// Start counting again, a failed reset is then retried once the threshold is reached again.
+		failures = 0
+		conns, err := m.redial(ctx)
+		if err != nil {
+			logger.Printf("error reconnecting to dapr sidecar: %v", err)
+			continue
+		}
+		if err := m.pool.reset(conns); err != nil {
+			logger.Printf("error closing previous connections to dapr sidecar: %v", err)
+		}
+	}
+}
+
+// close stops the monitor and waits for a running check or reset to complete.
+func (m *healthMonitor) close() {
+	close(m.stop)
+	<-m.done
+}
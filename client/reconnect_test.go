@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of the logger.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newLazyConn(t *testing.T) *grpc.ClientConn {
+	conn, err := grpc.DialContext(context.Background(), "passthrough:///unused", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestAutoReconnect$
+func TestAutoReconnect(t *testing.T) {
+	t.Run("unhealthy sidecar resets the connections", func(t *testing.T) {
+		out := &syncBuffer{}
+		previous := logger
+		SetLogger(log.New(out, "", 0))
+		t.Cleanup(func() { SetLogger(previous) })
+
+		initial := newLazyConn(t)
+		replacement := newLazyConn(t)
+		pool := newConnPool([]*grpc.ClientConn{initial})
+
+		// The sidecar fails 3 checks, then recovers once the connections were reset.
+		var checks, redials atomic.Int32
+		check := func(ctx context.Context) error {
+			if checks.Add(1) <= 3 {
+				return errors.New("sidecar unavailable")
+			}
+			return nil
+		}
+		redial := func(ctx context.Context) ([]*grpc.ClientConn, error) {
+			redials.Add(1)
+			return []*grpc.ClientConn{replacement}, nil
+		}
+		m := newHealthMonitor(reconnectConfig{interval: 5 * time.Millisecond, threshold: 3}, pool, check, redial)
+		m.start()
+		require.Eventually(t, func() bool {
+			return checks.Load() > 4
+		}, 5*time.Second, 5*time.Millisecond)
+		m.close()
+
+		assert.Equal(t, int32(1), redials.Load())
+		assert.Equal(t, []*grpc.ClientConn{replacement}, pool.connections())
+		assert.Equal(t, connectivity.Shutdown, initial.GetState())
+		assert.Contains(t, out.String(), "dapr sidecar unhealthy: sidecar unavailable")
+		assert.Contains(t, out.String(), "resetting connections to dapr sidecar after 3 failed health checks")
+	})
+
+	t.Run("healthy sidecar keeps the connections", func(t *testing.T) {
+		pool := newConnPool([]*grpc.ClientConn{newLazyConn(t)})
+		var checks atomic.Int32
+		m := newHealthMonitor(reconnectConfig{interval: 5 * time.Millisecond, threshold: 1}, pool, func(ctx context.Context) error {
+			checks.Add(1)
+			return nil
+		}, func(ctx context.Context) ([]*grpc.ClientConn, error) {
+			t.Error("unexpected reconnect")
+			return nil, errors.New("unexpected reconnect")
+		})
+		m.start()
+		require.Eventually(t, func() bool {
+			return checks.Load() > 3
+		}, 5*time.Second, 5*time.Millisecond)
+		m.close()
+	})
+
+	t.Run("reset waits for in-flight calls", func(t *testing.T) {
+		initial := newLazyConn(t)
+		replacement := newLazyConn(t)
+		pool := newConnPool([]*grpc.ClientConn{initial})
+		_, release, err := pool.acquire(true)
+		require.NoError(t, err)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = pool.reset([]*grpc.ClientConn{replacement})
+		}()
+		require.Eventually(t, func() bool {
+			return pool.pick() == replacement
+		}, 5*time.Second, time.Millisecond)
+		select {
+		case <-done:
+			t.Fatal("reset didn't wait for the in-flight call")
+		case <-time.After(20 * time.Millisecond):
+		}
+		assert.NotEqual(t, connectivity.Shutdown, initial.GetState())
+		release()
+		<-done
+		assert.Equal(t, connectivity.Shutdown, initial.GetState())
+	})
+
+	t.Run("reset doesn't wait for a hung call", func(t *testing.T) {
+		// The server never answers the calls, which then hang until their connection is closed.
+		lis := bufconn.Listen(testBufSize)
+		server := grpc.NewServer(grpc.UnknownServiceHandler(func(srv any, stream grpc.ServerStream) error {
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		}))
+		go server.Serve(lis)
+		t.Cleanup(server.Stop)
+		hung, err := grpc.DialContext(context.Background(), "bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.Dial() }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+
+		pool := newConnPool([]*grpc.ClientConn{hung})
+		pool.drainTimeout = 50 * time.Millisecond
+		called := make(chan error, 1)
+		go func() {
+			_, err := pb.NewDaprClient(pool).GetMetadata(context.Background(), &pb.GetMetadataRequest{})
+			called <- err
+		}()
+		require.Eventually(t, func() bool {
+			return hung.GetState() == connectivity.Ready
+		}, 5*time.Second, time.Millisecond)
+
+		replacement := newLazyConn(t)
+		reset := make(chan error, 1)
+		go func() { reset <- pool.reset([]*grpc.ClientConn{replacement}) }()
+		require.Eventually(t, func() bool {
+			return pool.pick() == replacement
+		}, 5*time.Second, time.Millisecond)
+
+		select {
+		case err := <-reset:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("reset waited for the hung call")
+		}
+		assert.Equal(t, connectivity.Shutdown, hung.GetState())
+		select {
+		case err := <-called:
+			require.Error(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("hung call not cancelled by the reset")
+		}
+	})
+
+	t.Run("connection accessors follow a reset", func(t *testing.T) {
+		ctx := context.Background()
+		port := startTestTCPServer(t)
+		c, err := NewClientWithOptions(ctx, WithPort(port), WithConnectionPool(2))
+		require.NoError(t, err)
+		defer c.Close()
+		client := c.(*GRPCClient)
+		initial := client.GrpcClientConn()
+		require.NoError(t, client.Wait(ctx, 5*time.Second))
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		states := client.WatchConnectionState(watchCtx)
+		assert.Equal(t, connectivity.Ready, <-states)
+
+		replacement, err := grpc.DialContext(ctx, "127.0.0.1:"+port, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		require.NoError(t, client.pool.reset([]*grpc.ClientConn{replacement}))
+		assert.Equal(t, connectivity.Shutdown, initial.GetState())
+		assert.Same(t, replacement, client.GrpcClientConn())
+		require.NoError(t, client.Wait(ctx, 5*time.Second))
+
+		// The watch reports the shutdown of the initial connection, then the states of the replacement.
+		for state := range states {
+			if state == connectivity.Ready {
+				break
+			}
+		}
+		_, err = c.GetState(ctx, testStore, "reconnect-key", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		ctx := context.Background()
+		_, err := NewClientWithOptions(ctx, WithPort("50001"), WithAutoReconnect(0))
+		require.Error(t, err)
+		_, err = NewClientWithOptions(ctx, WithPort("50001"), WithAutoReconnect(time.Second), WithReconnectThreshold(0))
+		require.Error(t, err)
+		_, err = NewClientWithOptions(ctx, WithPort("50001"), WithReconnectThreshold(2))
+		require.Error(t, err)
+	})
+}
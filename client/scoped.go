@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// ScopeOption configures a client returned by WithContext.
+type ScopeOption func(*scopedConn)
+
+// WithScopeMetadata adds the given gRPC metadata to every call of the scoped client.
+// Metadata already present in the outgoing context of a call wins.
+func WithScopeMetadata(md map[string]string) ScopeOption {
+	return func(s *scopedConn) {
+		for k, v := range md {
+			s.md[strings.ToLower(k)] = v
+		}
+	}
+}
+
+// WithScopeTimeout applies a timeout to every unary call of the scoped client
+// whose context has no deadline already.
+func WithScopeTimeout(timeout time.Duration) ScopeOption {
+	return func(s *scopedConn) {
+		s.timeout = timeout
+	}
+}
+
+// scopedConn applies the defaults of a scoped client to each call made on the underlying connection.
+type scopedConn struct {
+	grpc.ClientConnInterface
+	parent  context.Context
+	md      map[string]string
+	timeout time.Duration
+}
+
+func (s *scopedConn) withMetadata(ctx context.Context) context.Context {
+	out, _ := metadata.FromOutgoingContext(ctx)
+	for k, v := range s.md {
+		if len(out.Get(k)) == 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+		}
+	}
+	return ctx
+}
+
+// unary returns the context of a unary call with the scope defaults, cancelled when the scope context is done.
+func (s *scopedConn) unary(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if err := s.parent.Err(); err != nil {
+		return nil, nil, status.FromContextError(err).Err()
+	}
+	ctx, cancel := context.WithCancel(s.withMetadata(ctx))
+	stop := context.AfterFunc(s.parent, cancel)
+	if _, ok := ctx.Deadline(); !ok && s.timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, s.timeout)
+		return ctx, func() {
+			cancelTimeout()
+			stop()
+			cancel()
+		}, nil
+	}
+	return ctx, func() {
+		stop()
+		cancel()
+	}, nil
+}
+
+// Invoke performs a unary call with the scope defaults, cancelling it when the scope context is done.
+func (s *scopedConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	ctx, cancel, err := s.unary(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return s.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream begins a streaming call with the scope metadata. Streams usually outlive the request
+// the scope is bound to, so neither the scope timeout nor its context apply.
+func (s *scopedConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return s.ClientConnInterface.NewStream(s.withMetadata(ctx), desc, method, opts...)
+}
+
+// WithContext returns a client bound to ctx, typically the context of an incoming request.
+// Unary calls made through the returned client are cancelled once ctx is done and inherit the
+// metadata and timeout given as options unless the context of the call overrides them.
+// The returned client shares the connection of c, or its connection pool, closing it has no effect.
+// A client without a connection, such as one wrapping a DaprClient stub, shares its DaprClient:
+// the scope defaults are then applied to the context of each call made through the Dapr API methods,
+// the client-side streams of EncryptAlpha1 and DecryptAlpha1 excepted.
+func (c *GRPCClient) WithContext(ctx context.Context, opts ...ScopeOption) Client {
+	switch {
+	case c.pool != nil:
+		return c.scoped(ctx, c.pool, opts...)
+	case c.connection != nil:
+		return c.scoped(ctx, c.connection, opts...)
+	default:
+		scoped := *c
+		scoped.scope = newScopedConn(ctx, nil, opts...)
+		scoped.monitor = nil
+		scoped.shared = true
+		return &scoped
+	}
+}
+
+func newScopedConn(ctx context.Context, base grpc.ClientConnInterface, opts ...ScopeOption) *scopedConn {
+	conn := &scopedConn{
+		ClientConnInterface: base,
+		parent:              ctx,
+		md:                  make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(conn)
+	}
+	return conn
+}
+
+func (c *GRPCClient) scoped(ctx context.Context, base grpc.ClientConnInterface, opts ...ScopeOption) *GRPCClient {
+	scoped := *c
+	scoped.protoClient = pb.NewDaprClient(newScopedConn(ctx, base, opts...))
+	scoped.scope = nil
+	scoped.monitor = nil
+	scoped.shared = true
+	return &scoped
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// captureConn records the context of the last unary call.
+type captureConn struct {
+	grpc.ClientConnInterface
+	ctx context.Context
+}
+
+func (c *captureConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	c.ctx = ctx
+	return ctx.Err()
+}
+
+// ctxRecordingClient records the context of the last GetState call.
+type ctxRecordingClient struct {
+	pb.DaprClient
+	ctx context.Context
+}
+
+func (c *ctxRecordingClient) GetState(ctx context.Context, in *pb.GetStateRequest, opts ...grpc.CallOption) (*pb.GetStateResponse, error) {
+	c.ctx = ctx
+	return &pb.GetStateResponse{}, ctx.Err()
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestWithContext$
+func TestWithContext(t *testing.T) {
+	ctx := context.Background()
+	opts := []ScopeOption{
+		WithScopeMetadata(map[string]string{"Tenant-ID": "t1", "region": "eu"}),
+		WithScopeTimeout(time.Minute),
+	}
+
+	t.Run("scope defaults are applied", func(t *testing.T) {
+		conn := &captureConn{}
+		c := (&GRPCClient{}).scoped(ctx, conn, opts...)
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+
+		md, _ := metadata.FromOutgoingContext(conn.ctx)
+		assert.Equal(t, []string{"t1"}, md.Get("tenant-id"))
+		assert.Equal(t, []string{"eu"}, md.Get("region"))
+		deadline, ok := conn.ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	})
+
+	t.Run("per call values win", func(t *testing.T) {
+		conn := &captureConn{}
+		c := (&GRPCClient{}).scoped(ctx, conn, opts...)
+		callCtx := metadata.AppendToOutgoingContext(ctx, "tenant-id", "t2")
+		callCtx, cancel := context.WithTimeout(callCtx, time.Second)
+		defer cancel()
+		_, err := c.GetState(callCtx, testStore, "key1", nil)
+		require.NoError(t, err)
+
+		md, _ := metadata.FromOutgoingContext(conn.ctx)
+		assert.Equal(t, []string{"t2"}, md.Get("tenant-id"))
+		assert.Equal(t, []string{"eu"}, md.Get("region"))
+		deadline, ok := conn.ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, time.Second)
+	})
+
+	t.Run("calls are cancelled with the scope context", func(t *testing.T) {
+		scopeCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		c := (&GRPCClient{}).scoped(scopeCtx, &captureConn{})
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.Error(t, err)
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	})
+
+	t.Run("scoped client shares the connection", func(t *testing.T) {
+		scoped := testClient.WithContext(ctx, opts...)
+		require.NoError(t, scoped.SaveState(ctx, testStore, "scoped-key", []byte(testData), nil))
+		scoped.Close()
+		require.NoError(t, testClient.DeleteState(ctx, testStore, "scoped-key", nil))
+	})
+
+	t.Run("scoped client shares the connection pool", func(t *testing.T) {
+		c, err := NewClientWithOptions(ctx, WithPort(startTestTCPServer(t)), WithConnectionPool(2))
+		require.NoError(t, err)
+		defer c.Close()
+		pool := c.(*GRPCClient).pool
+		picked := pool.next.Load()
+
+		scoped := c.(*GRPCClient).WithContext(ctx, opts...)
+		for i := 0; i < 3; i++ {
+			_, err := scoped.GetState(ctx, testStore, "scoped-key", nil)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, picked+3, pool.next.Load())
+		scoped.Close()
+		_, err = c.GetState(ctx, testStore, "scoped-key", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("client without connection applies the scope defaults", func(t *testing.T) {
+		fake := &ctxRecordingClient{}
+		scoped := NewClientWithConnection(nil).WithContext(ctx, opts...)
+		scoped.(*GRPCClient).protoClient = fake
+		_, err := scoped.GetState(ctx, testStore, "scoped-key", nil)
+		require.NoError(t, err)
+
+		md, _ := metadata.FromOutgoingContext(fake.ctx)
+		assert.Equal(t, []string{"t1"}, md.Get("tenant-id"))
+		assert.Equal(t, []string{"eu"}, md.Get("region"))
+		deadline, ok := fake.ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	})
+
+	t.Run("client without connection is cancelled with the scope context", func(t *testing.T) {
+		scopeCtx, cancel := context.WithCancel(ctx)
+		fake := &ctxRecordingClient{}
+		scoped := (&GRPCClient{protoClient: fake}).WithContext(scopeCtx)
+		_, err := scoped.GetState(ctx, testStore, "scoped-key", nil)
+		require.NoError(t, err)
+		cancel()
+		_, err = scoped.GetState(ctx, testStore, "scoped-key", nil)
+		require.Error(t, err)
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	})
+}
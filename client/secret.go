@@ -17,6 +17,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
@@ -24,7 +30,7 @@ import (
 // GetSecret retrieves preconfigured secret from specified store using key.
 func (c *GRPCClient) GetSecret(ctx context.Context, storeName, key string, meta map[string]string) (data map[string]string, err error) {
 	if storeName == "" {
-		return nil, errors.New("empty storeName")
+		return nil, missingComponentName("secret store")
 	}
 	if key == "" {
 		return nil, errors.New("empty key")
@@ -36,7 +42,7 @@ func (c *GRPCClient) GetSecret(ctx context.Context, storeName, key string, meta
 		Metadata:  meta,
 	}
 
-	resp, err := c.protoClient.GetSecret(ctx, req)
+	resp, err := callDapr(ctx, c, "GetSecret", req, c.protoClient.GetSecret)
 	if err != nil {
 		return nil, fmt.Errorf("error invoking service: %w", err)
 	}
@@ -48,10 +54,65 @@ func (c *GRPCClient) GetSecret(ctx context.Context, storeName, key string, meta
 	return
 }
 
+// GetSecretWithFallback retrieves a secret like GetSecret, invoking fallback instead when the secret
+// store isn't configured or the secret doesn't exist, for example during local development.
+// Any other error, such as a denied permission, is returned without invoking fallback.
+func (c *GRPCClient) GetSecretWithFallback(ctx context.Context, storeName, key string, meta map[string]string, fallback func() (map[string]string, error)) (map[string]string, error) {
+	if fallback == nil {
+		return nil, errors.New("nil fallback")
+	}
+	data, err := c.GetSecret(ctx, storeName, key, meta)
+	if err == nil {
+		return data, nil
+	}
+	if !IsSecretStoreNotConfigured(err) && !IsSecretNotFound(err) {
+		return nil, err
+	}
+	data, ferr := fallback()
+	if ferr != nil {
+		return nil, fmt.Errorf("error loading fallback secret after %w: %w", err, ferr)
+	}
+	return data, nil
+}
+
+// IsSecretStoreNotConfigured reports whether err indicates the secret store isn't configured
+// or doesn't exist on the sidecar.
+func IsSecretStoreNotConfigured(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch st.Code() {
+	case codes.FailedPrecondition:
+		return strings.Contains(st.Message(), "secret store is not configured")
+	case codes.InvalidArgument:
+		return strings.Contains(st.Message(), "failed finding secret store")
+	default:
+		return false
+	}
+}
+
+// IsSecretNotFound reports whether err indicates the secret doesn't exist in the secret store.
+// The runtime reports component errors as internal errors, so their message is inspected.
+func IsSecretNotFound(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch st.Code() {
+	case codes.NotFound:
+		return true
+	case codes.Internal:
+		return strings.Contains(st.Message(), "failed getting secret") && strings.Contains(strings.ToLower(st.Message()), "not found")
+	default:
+		return false
+	}
+}
+
 // GetBulkSecret retrieves all preconfigured secrets for this application.
 func (c *GRPCClient) GetBulkSecret(ctx context.Context, storeName string, meta map[string]string) (data map[string]map[string]string, err error) {
 	if storeName == "" {
-		return nil, errors.New("empty storeName")
+		return nil, missingComponentName("secret store")
 	}
 
 	req := &pb.GetBulkSecretRequest{
@@ -59,7 +120,7 @@ func (c *GRPCClient) GetBulkSecret(ctx context.Context, storeName string, meta m
 		Metadata:  meta,
 	}
 
-	resp, err := c.protoClient.GetBulkSecret(ctx, req)
+	resp, err := callDapr(ctx, c, "GetBulkSecret", req, c.protoClient.GetBulkSecret)
 	if err != nil {
 		return nil, fmt.Errorf("error invoking service: %w", err)
 	}
@@ -78,3 +139,92 @@ func (c *GRPCClient) GetBulkSecret(ctx context.Context, storeName string, meta m
 
 	return
 }
+
+const secretPollIntervalDefault = 30 * time.Second
+
+// SecretChangeHandler is invoked with the key and new value of a watched secret when it changes.
+type SecretChangeHandler func(key string, value map[string]string)
+
+// SecretSubscribeOption configures SubscribeSecrets.
+type SecretSubscribeOption func(*secretSubscribeOptions)
+
+type secretSubscribeOptions struct {
+	interval time.Duration
+	meta     map[string]string
+}
+
+// WithSecretPollInterval sets the interval at which watched secrets are polled, defaults to 30 seconds.
+func WithSecretPollInterval(interval time.Duration) SecretSubscribeOption {
+	return func(o *secretSubscribeOptions) {
+		o.interval = interval
+	}
+}
+
+// WithSecretMetadata sets the metadata passed to the secret store on each poll.
+func WithSecretMetadata(meta map[string]string) SecretSubscribeOption {
+	return func(o *secretSubscribeOptions) {
+		o.meta = meta
+	}
+}
+
+// SubscribeSecrets watches the given secrets and invokes handler whenever one of them changes.
+// The Dapr runtime doesn't push secret changes, so the secrets are polled with GetSecret at a fixed
+// interval: a change is observed up to one interval after it happens and every poll issues one
+// GetSecret call per key. The current values are read before SubscribeSecrets returns and are not
+// reported to the handler. Errors while polling are logged and the previous value is kept.
+// The handler is invoked from a single goroutine, polling stops when ctx is cancelled.
+func (c *GRPCClient) SubscribeSecrets(ctx context.Context, storeName string, keys []string, handler SecretChangeHandler, opts ...SecretSubscribeOption) error {
+	if storeName == "" {
+		return missingComponentName("secret store")
+	}
+	if len(keys) == 0 {
+		return errors.New("keys required")
+	}
+	if handler == nil {
+		return errors.New("handler required")
+	}
+	o := &secretSubscribeOptions{interval: secretPollIntervalDefault}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.interval <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+
+	current := make(map[string]map[string]string, len(keys))
+	for _, key := range keys {
+		value, err := c.GetSecret(ctx, storeName, key, o.meta)
+		if err != nil {
+			return fmt.Errorf("error reading secret %s: %w", key, err)
+		}
+		current[key] = value
+	}
+
+	go func() {
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			for _, key := range keys {
+				value, err := c.GetSecret(ctx, storeName, key, o.meta)
+				if err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					logger.Printf("error polling secret %s from store %s: %v", key, storeName, err)
+					continue
+				}
+				if !maps.Equal(current[key], value) {
+					current[key] = value
+					handler(key, value)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
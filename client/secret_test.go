@@ -15,11 +15,19 @@ package client
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
 
 // go test -timeout 30s ./client -count 1 -run ^TestGetSecret$
@@ -74,3 +82,152 @@ func TestGetBulkSecret(t *testing.T) {
 		assert.NotNil(t, out)
 	})
 }
+
+type changingSecretClient struct {
+	pb.DaprClient
+	mu      sync.Mutex
+	secrets map[string]map[string]string
+}
+
+func (c *changingSecretClient) set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.secrets[key] = map[string]string{key: value}
+}
+
+func (c *changingSecretClient) GetSecret(ctx context.Context, in *pb.GetSecretRequest, opts ...grpc.CallOption) (*pb.GetSecretResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.secrets[in.GetKey()]
+	if !ok {
+		return nil, errors.New("secret not found")
+	}
+	return &pb.GetSecretResponse{Data: data}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestSubscribeSecrets$
+func TestSubscribeSecrets(t *testing.T) {
+	ctx := context.Background()
+	noop := func(string, map[string]string) {}
+
+	t.Run("without store", func(t *testing.T) {
+		err := testClient.SubscribeSecrets(ctx, "", []string{"key1"}, noop)
+		require.Error(t, err)
+	})
+
+	t.Run("without keys", func(t *testing.T) {
+		err := testClient.SubscribeSecrets(ctx, "store", nil, noop)
+		require.Error(t, err)
+	})
+
+	t.Run("without handler", func(t *testing.T) {
+		err := testClient.SubscribeSecrets(ctx, "store", []string{"key1"}, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("with invalid interval", func(t *testing.T) {
+		err := testClient.SubscribeSecrets(ctx, "store", []string{"key1"}, noop, WithSecretPollInterval(0))
+		require.Error(t, err)
+	})
+
+	t.Run("initial read error", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &changingSecretClient{secrets: map[string]map[string]string{}}}
+		err := c.SubscribeSecrets(ctx, "store", []string{"missing"}, noop)
+		require.Error(t, err)
+	})
+
+	t.Run("emits on change only", func(t *testing.T) {
+		proto := &changingSecretClient{secrets: map[string]map[string]string{}}
+		proto.set("key1", "v1")
+		proto.set("key2", "v1")
+		c := &GRPCClient{protoClient: proto}
+
+		changes := make(chan string, 10)
+		subCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		err := c.SubscribeSecrets(subCtx, "store", []string{"key1", "key2"}, func(key string, value map[string]string) {
+			changes <- key + "=" + value[key]
+		}, WithSecretPollInterval(10*time.Millisecond))
+		require.NoError(t, err)
+
+		select {
+		case change := <-changes:
+			t.Fatalf("unexpected change before update: %s", change)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		proto.set("key2", "v2")
+		select {
+		case change := <-changes:
+			assert.Equal(t, "key2=v2", change)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for secret change")
+		}
+
+		select {
+		case change := <-changes:
+			t.Fatalf("unexpected repeated change: %s", change)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+type erroringSecretClient struct {
+	pb.DaprClient
+	err error
+}
+
+func (c *erroringSecretClient) GetSecret(ctx context.Context, in *pb.GetSecretRequest, opts ...grpc.CallOption) (*pb.GetSecretResponse, error) {
+	return nil, c.err
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestGetSecretWithFallback$
+func TestGetSecretWithFallback(t *testing.T) {
+	ctx := context.Background()
+	fallbackCalls := 0
+	fallback := func() (map[string]string, error) {
+		fallbackCalls++
+		return map[string]string{"key1": "local"}, nil
+	}
+
+	t.Run("secret from store", func(t *testing.T) {
+		fallbackCalls = 0
+		out, err := testClient.GetSecretWithFallback(ctx, "store", "key1", nil, fallback)
+		require.NoError(t, err)
+		assert.NotEqual(t, "local", out["key1"])
+		assert.Equal(t, 0, fallbackCalls)
+	})
+
+	fallbackErrs := map[string]error{
+		"store not configured": status.Error(codes.FailedPrecondition, "secret store is not configured"),
+		"store not found":      status.Error(codes.InvalidArgument, "failed finding secret store with key store"),
+		"secret not found":     status.Error(codes.Internal, "failed getting secret with key key1 from secret store store: secret key1 not found"),
+	}
+	for name, protoErr := range fallbackErrs {
+		t.Run(name+" uses fallback", func(t *testing.T) {
+			fallbackCalls = 0
+			c := &GRPCClient{protoClient: &erroringSecretClient{err: protoErr}}
+			out, err := c.GetSecretWithFallback(ctx, "store", "key1", nil, fallback)
+			require.NoError(t, err)
+			assert.Equal(t, "local", out["key1"])
+			assert.Equal(t, 1, fallbackCalls)
+		})
+	}
+
+	t.Run("hard error is propagated", func(t *testing.T) {
+		fallbackCalls = 0
+		c := &GRPCClient{protoClient: &erroringSecretClient{err: status.Error(codes.PermissionDenied, `access denied by policy to get "key1" from "store"`)}}
+		_, err := c.GetSecretWithFallback(ctx, "store", "key1", nil, fallback)
+		require.Error(t, err)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Equal(t, 0, fallbackCalls)
+	})
+
+	t.Run("fallback error", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &erroringSecretClient{err: status.Error(codes.NotFound, "not found")}}
+		_, err := c.GetSecretWithFallback(ctx, "store", "key1", nil, func() (map[string]string, error) {
+			return nil, errors.New("no local secret")
+		})
+		require.ErrorContains(t, err, "no local secret")
+	})
+}
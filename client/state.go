@@ -14,9 +14,12 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -152,6 +155,48 @@ type BulkStateItem struct {
 	Error    string
 }
 
+const (
+	stateMetadataKeyTTLExpireTime = "ttlExpireTime"
+	stateMetadataKeyContentType   = "contentType"
+)
+
+// StateMetadata provides typed access to the metadata returned by the state store with a state item.
+type StateMetadata map[string]string
+
+// Get returns the raw value of the metadata key.
+func (m StateMetadata) Get(key string) string {
+	return m[key]
+}
+
+// TTLExpireTime returns the time the item expires at, false when the item has no TTL
+// or the store returned a value that isn't a RFC3339 timestamp.
+func (m StateMetadata) TTLExpireTime() (time.Time, bool) {
+	v, ok := m[stateMetadataKeyTTLExpireTime]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// ContentType returns the content type of the item value, empty when not set.
+func (m StateMetadata) ContentType() string {
+	return m[stateMetadataKeyContentType]
+}
+
+// StateMetadata returns the item metadata with typed accessors.
+func (i *StateItem) StateMetadata() StateMetadata {
+	return i.Metadata
+}
+
+// StateMetadata returns the item metadata with typed accessors.
+func (i *BulkStateItem) StateMetadata() StateMetadata {
+	return i.Metadata
+}
+
 // SetStateItem represents a single state to be persisted.
 type SetStateItem struct {
 	Key      string
@@ -188,6 +233,14 @@ type ETag struct {
 type StateOptions struct {
 	Concurrency StateConcurrency
 	Consistency StateConsistency
+
+	// ttlMin and ttlMax bound the ttlInSeconds metadata value, they are validated
+	// client-side and never sent to the runtime.
+	ttlMin time.Duration
+	ttlMax time.Duration
+
+	// skipUnchanged reads the current value before saving and skips identical writes.
+	skipUnchanged bool
 }
 
 // StateOption StateOptions's function type.
@@ -207,6 +260,54 @@ func WithConsistency(consistency StateConsistency) StateOption {
 	}
 }
 
+// WithStateTTLBounds validates the ttlInSeconds metadata of saved items against the given bounds
+// before the request is sent, a zero value disables the respective bound.
+// A TTL of -1 (never expire) is rejected when a maximum is set.
+func WithStateTTLBounds(minTTL, maxTTL time.Duration) StateOption {
+	return func(so *StateOptions) {
+		so.ttlMin = minTTL
+		so.ttlMax = maxTTL
+	}
+}
+
+// WithSkipUnchangedWrites reads the current value of the key before saving and skips the write
+// when the value is identical. Every save then costs an additional state read,
+// use SaveStateChanged to learn whether a write occurred. Saves with an ETag or with
+// ttlInSeconds metadata are always written, as are saves to a missing key.
+func WithSkipUnchangedWrites() StateOption {
+	return func(so *StateOptions) {
+		so.skipUnchanged = true
+	}
+}
+
+func validateStateTTL(si *SetStateItem) error {
+	if si.Options == nil || (si.Options.ttlMin <= 0 && si.Options.ttlMax <= 0) {
+		return nil
+	}
+	val, ok := si.Metadata[metadataKeyTTLInSeconds]
+	if !ok {
+		return nil
+	}
+	secs, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s value %q for key %s: %w", metadataKeyTTLInSeconds, val, si.Key, err)
+	}
+	if secs == -1 {
+		if si.Options.ttlMax > 0 {
+			return fmt.Errorf("%s for key %s never expires, which exceeds the maximum of %s", metadataKeyTTLInSeconds, si.Key, si.Options.ttlMax)
+		}
+		return nil
+	}
+	ttl := time.Duration(secs) * time.Second
+	if si.Options.ttlMin > 0 && ttl < si.Options.ttlMin {
+		return fmt.Errorf("%s for key %s is %s, which is below the minimum of %s", metadataKeyTTLInSeconds, si.Key, ttl, si.Options.ttlMin)
+	}
+	if si.Options.ttlMax > 0 && ttl > si.Options.ttlMax {
+		return fmt.Errorf("%s for key %s is %s, which exceeds the maximum of %s", metadataKeyTTLInSeconds, si.Key, ttl, si.Options.ttlMax)
+	}
+	return nil
+}
+
 func toProtoSaveStateItem(si *SetStateItem) (item *v1.StateItem) {
 	s := &v1.StateItem{
 		Key:      si.Key,
@@ -259,29 +360,56 @@ func toProtoDuration(d time.Duration) *durationpb.Duration {
 }
 
 // ExecuteStateTransaction provides way to execute multiple operations on a specified store.
+// All operations are applied atomically against the single store named storeName, transactions
+// spanning multiple stores are not supported and must be split into one call per store.
 func (c *GRPCClient) ExecuteStateTransaction(ctx context.Context, storeName string, meta map[string]string, ops []*StateOperation) error {
-	if storeName == "" {
-		return errors.New("nil storeName")
+	storeName = c.stateStoreName(storeName)
+	if strings.TrimSpace(storeName) == "" {
+		return fmt.Errorf("%w: a transaction executes against a single state store, use one ExecuteStateTransaction call per store", missingComponentName("state store"))
+	}
+	if storeName != strings.TrimSpace(storeName) {
+		return fmt.Errorf("invalid state store name %q: name must not contain leading or trailing whitespace", storeName)
 	}
 	if len(ops) == 0 {
 		return nil
 	}
 
 	items := make([]*pb.TransactionalStateOperation, 0)
-	for _, op := range ops {
+	for i, op := range ops {
+		if op == nil || op.Item == nil {
+			return fmt.Errorf("state transaction operation %d on store %s has no item", i, storeName)
+		}
+		if err := c.checkStateKeys(op.Item.Key); err != nil {
+			return err
+		}
+		if op.Type == StateOperationTypeUpsert {
+			if err := validateStateTTL(op.Item); err != nil {
+				return err
+			}
+		}
 		item := &pb.TransactionalStateOperation{
 			OperationType: op.Type.String(),
 			Request:       toProtoSaveStateItem(op.Item),
 		}
+		if op.Type == StateOperationTypeUpsert {
+			if err := c.encryptStateItem(ctx, item.Request); err != nil {
+				return err
+			}
+		}
 		items = append(items, item)
 	}
 
+	c.traceStateTransaction(ctx, storeName, ops)
+
 	req := &pb.ExecuteStateTransactionRequest{
 		Metadata:   meta,
 		StoreName:  storeName,
 		Operations: items,
 	}
-	_, err := c.protoClient.ExecuteStateTransaction(ctx, req)
+	_, err := callDapr(ctx, c, "ExecuteStateTransaction", req, c.protoClient.ExecuteStateTransaction)
+	for _, op := range ops {
+		c.invalidateCachedState(storeName, op.Item.Key)
+	}
 	if err != nil {
 		return fmt.Errorf("error executing state transaction: %w", err)
 	}
@@ -293,14 +421,28 @@ func (c *GRPCClient) SaveState(ctx context.Context, storeName, key string, data
 	return c.SaveStateWithETag(ctx, storeName, key, data, "", meta, so...)
 }
 
+// SaveStateChanged saves the raw data into store like SaveState and reports whether a write occurred.
+// Combined with WithSkipUnchangedWrites it returns false when the stored value was already identical.
+func (c *GRPCClient) SaveStateChanged(ctx context.Context, storeName, key string, data []byte, meta map[string]string, so ...StateOption) (bool, error) {
+	return c.saveStateWithETag(ctx, storeName, key, data, "", meta, so...)
+}
+
 // SaveStateWithETag saves the raw data into store using provided state options and etag.
 func (c *GRPCClient) SaveStateWithETag(ctx context.Context, storeName, key string, data []byte, etag string, meta map[string]string, so ...StateOption) error {
+	_, err := c.saveStateWithETag(ctx, storeName, key, data, etag, meta, so...)
+	return err
+}
+
+func (c *GRPCClient) saveStateWithETag(ctx context.Context, storeName, key string, data []byte, etag string, meta map[string]string, so ...StateOption) (bool, error) {
+	storeName = c.stateStoreName(storeName)
 	stateOptions := new(StateOptions)
 	for _, o := range so {
 		o(stateOptions)
 	}
-	if len(so) == 0 {
-		stateOptions = copyStateOptionDefault()
+	if stateOptions.Concurrency == StateConcurrencyUndefined && stateOptions.Consistency == StateConsistencyUndefined {
+		defaults := copyStateOptionDefault()
+		stateOptions.Concurrency = defaults.Concurrency
+		stateOptions.Consistency = defaults.Consistency
 	}
 	item := &SetStateItem{
 		Key:      key,
@@ -311,13 +453,36 @@ func (c *GRPCClient) SaveStateWithETag(ctx context.Context, storeName, key strin
 	if etag != "" {
 		item.Etag = &ETag{Value: etag}
 	}
-	return c.SaveBulkState(ctx, storeName, item)
+	// A write with an ETag or a TTL has effects beyond the value, so it is never skipped.
+	_, hasTTL := meta[metadataKeyTTLInSeconds]
+	if stateOptions.skipUnchanged && etag == "" && !hasTTL {
+		current, err := c.GetStateWithConsistency(ctx, storeName, key, nil, stateOptions.Consistency)
+		if err != nil {
+			return false, fmt.Errorf("error reading current state: %w", err)
+		}
+		// A missing key has no ETag, writing an empty value to it is still a change.
+		if current.Etag != "" && bytes.Equal(current.Value, data) {
+			return false, nil
+		}
+	}
+	if err := c.saveStateItems(ctx, storeName, item); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // SaveBulkState saves the multiple state item to store.
 func (c *GRPCClient) SaveBulkState(ctx context.Context, storeName string, items ...*SetStateItem) error {
+	storeName = c.stateStoreName(storeName)
+	start := time.Now()
+	err := c.saveStateItems(ctx, storeName, items...)
+	c.observeBulkState(BulkStateSave, storeName, len(items), start, err)
+	return err
+}
+
+func (c *GRPCClient) saveStateItems(ctx context.Context, storeName string, items ...*SetStateItem) error {
 	if storeName == "" {
-		return errors.New("nil store")
+		return missingComponentName("state store")
 	}
 	if items == nil {
 		return errors.New("nil item")
@@ -329,11 +494,23 @@ func (c *GRPCClient) SaveBulkState(ctx context.Context, storeName string, items
 	}
 
 	for _, si := range items {
+		if err := c.checkStateKeys(si.Key); err != nil {
+			return err
+		}
+		if err := validateStateTTL(si); err != nil {
+			return err
+		}
 		item := toProtoSaveStateItem(si)
+		if err := c.encryptStateItem(ctx, item); err != nil {
+			return err
+		}
 		req.States = append(req.GetStates(), item)
 	}
 
-	_, err := c.protoClient.SaveState(ctx, req)
+	_, err := callDapr(ctx, c, "SaveState", req, c.protoClient.SaveState)
+	for _, si := range items {
+		c.invalidateCachedState(storeName, si.Key)
+	}
 	if err != nil {
 		return fmt.Errorf("error saving state: %w", err)
 	}
@@ -342,12 +519,23 @@ func (c *GRPCClient) SaveBulkState(ctx context.Context, storeName string, items
 
 // GetBulkState retrieves state for multiple keys from specific store.
 func (c *GRPCClient) GetBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string, parallelism int32) ([]*BulkStateItem, error) {
+	storeName = c.stateStoreName(storeName)
+	start := time.Now()
+	items, err := c.getBulkState(ctx, storeName, keys, meta, parallelism)
+	c.observeBulkState(BulkStateGet, storeName, len(keys), start, err)
+	return items, err
+}
+
+func (c *GRPCClient) getBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string, parallelism int32) ([]*BulkStateItem, error) {
 	if storeName == "" {
-		return nil, errors.New("nil store")
+		return nil, missingComponentName("state store")
 	}
 	if len(keys) == 0 {
 		return nil, errors.New("keys required")
 	}
+	if err := c.checkStateKeys(keys...); err != nil {
+		return nil, err
+	}
 	items := make([]*BulkStateItem, 0)
 
 	req := &pb.GetBulkStateRequest{
@@ -357,7 +545,7 @@ func (c *GRPCClient) GetBulkState(ctx context.Context, storeName string, keys []
 		Parallelism: parallelism,
 	}
 
-	results, err := c.protoClient.GetBulkState(ctx, req)
+	results, err := callDapr(ctx, c, "GetBulkState", req, c.protoClient.GetBulkState)
 	if err != nil {
 		return nil, fmt.Errorf("error getting state: %w", err)
 	}
@@ -374,12 +562,90 @@ func (c *GRPCClient) GetBulkState(ctx context.Context, storeName string, keys []
 			Metadata: r.GetMetadata(),
 			Error:    r.GetError(),
 		}
+		if item.Error == "" {
+			if item.Value, item.Metadata, err = c.decryptStateValue(ctx, item.Value, item.Metadata); err != nil {
+				item.Value, item.Metadata, item.Error = nil, r.GetMetadata(), err.Error()
+			}
+		}
 		items = append(items, item)
 	}
 
 	return items, nil
 }
 
+// GetBulkStateAs retrieves state for multiple keys from specific store and decodes each value into T
+// with the configured codec, JSON by default.
+// Keys which are missing from the store are absent from the returned values map.
+// Keys which could not be retrieved or decoded are reported in the returned errors map.
+func GetBulkStateAs[T any](ctx context.Context, c Client, storeName string, keys []string, parallelism int, opts ...TypedStateOption) (map[string]T, map[string]error) {
+	o := newTypedStateOptions(opts)
+	values := make(map[string]T, len(keys))
+	errs := make(map[string]error)
+
+	items, err := c.GetBulkState(ctx, storeName, keys, nil, int32(parallelism))
+	if err != nil {
+		for _, k := range keys {
+			errs[k] = err
+		}
+		return values, errs
+	}
+
+	for _, item := range items {
+		if item.Error != "" {
+			errs[item.Key] = fmt.Errorf("error getting state: %s", item.Error)
+			continue
+		}
+		if len(item.Value) == 0 {
+			continue
+		}
+		var v T
+		if err := o.codec.Unmarshal(item.Value, &v); err != nil {
+			errs[item.Key] = fmt.Errorf("error decoding state: %w", err)
+			continue
+		}
+		values[item.Key] = v
+	}
+
+	return values, errs
+}
+
+// GetBulkStateAsSlice retrieves state for multiple keys from specific store and decodes the values into
+// a slice of T with the configured codec, JSON by default. Values are returned in the order of keys,
+// and the keys missing from the store are returned in input order. Keys which could not be
+// retrieved or decoded are skipped and their errors are joined into the returned error.
+func GetBulkStateAsSlice[T any](ctx context.Context, c Client, storeName string, keys []string, parallelism int, opts ...TypedStateOption) ([]T, []string, error) {
+	o := newTypedStateOptions(opts)
+	items, err := c.GetBulkState(ctx, storeName, keys, nil, int32(parallelism))
+	if err != nil {
+		return nil, nil, err
+	}
+	byKey := make(map[string]*BulkStateItem, len(items))
+	for _, item := range items {
+		byKey[item.Key] = item
+	}
+
+	values := make([]T, 0, len(keys))
+	missing := make([]string, 0)
+	var errs []error
+	for _, key := range keys {
+		item, ok := byKey[key]
+		switch {
+		case ok && item.Error != "":
+			errs = append(errs, fmt.Errorf("error getting state of key %s: %s", key, item.Error))
+		case !ok || len(item.Value) == 0:
+			missing = append(missing, key)
+		default:
+			var v T
+			if err := o.codec.Unmarshal(item.Value, &v); err != nil {
+				errs = append(errs, fmt.Errorf("error decoding state of key %s: %w", key, err))
+				continue
+			}
+			values = append(values, v)
+		}
+	}
+	return values, missing, errors.Join(errs...)
+}
+
 // GetState retrieves state from specific store using default consistency option.
 func (c *GRPCClient) GetState(ctx context.Context, storeName, key string, meta map[string]string) (item *StateItem, err error) {
 	return c.GetStateWithConsistency(ctx, storeName, key, meta, StateConsistencyStrong)
@@ -387,10 +653,22 @@ func (c *GRPCClient) GetState(ctx context.Context, storeName, key string, meta m
 
 // GetStateWithConsistency retrieves state from specific store using provided state consistency.
 func (c *GRPCClient) GetStateWithConsistency(ctx context.Context, storeName, key string, meta map[string]string, sc StateConsistency) (*StateItem, error) {
+	storeName = c.stateStoreName(storeName)
 	if err := hasRequiredStateArgs(storeName, key); err != nil {
 		return nil, fmt.Errorf("missing required arguments: %w", err)
 	}
+	if err := c.checkStateKeys(key); err != nil {
+		return nil, err
+	}
+	if c.stateCache != nil && len(meta) == 0 {
+		return c.stateCache.get(ctx, storeName, key, func(ctx context.Context) (*StateItem, error) {
+			return c.getState(ctx, storeName, key, meta, sc)
+		})
+	}
+	return c.getState(ctx, storeName, key, meta, sc)
+}
 
+func (c *GRPCClient) getState(ctx context.Context, storeName, key string, meta map[string]string, sc StateConsistency) (*StateItem, error) {
 	req := &pb.GetStateRequest{
 		StoreName:   storeName,
 		Key:         key,
@@ -398,23 +676,28 @@ func (c *GRPCClient) GetStateWithConsistency(ctx context.Context, storeName, key
 		Metadata:    meta,
 	}
 
-	result, err := c.protoClient.GetState(ctx, req)
+	result, err := callDapr(ctx, c, "GetState", req, c.protoClient.GetState)
 	if err != nil {
 		return nil, fmt.Errorf("error getting state: %w", err)
 	}
+	value, itemMeta, err := c.decryptStateValue(ctx, result.GetData(), result.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
 
 	return &StateItem{
 		Etag:     result.GetEtag(),
 		Key:      key,
-		Value:    result.GetData(),
-		Metadata: result.GetMetadata(),
+		Value:    value,
+		Metadata: itemMeta,
 	}, nil
 }
 
 // QueryStateAlpha1 runs a query against state store.
 func (c *GRPCClient) QueryStateAlpha1(ctx context.Context, storeName, query string, meta map[string]string) (*QueryResponse, error) {
+	storeName = c.stateStoreName(storeName)
 	if storeName == "" {
-		return nil, errors.New("store name is not set")
+		return nil, missingComponentName("state store")
 	}
 	if query == "" {
 		return nil, errors.New("query is not set")
@@ -424,7 +707,7 @@ func (c *GRPCClient) QueryStateAlpha1(ctx context.Context, storeName, query stri
 		Query:     query,
 		Metadata:  meta,
 	}
-	resp, err := c.protoClient.QueryStateAlpha1(ctx, req)
+	resp, err := callDapr(ctx, c, "QueryStateAlpha1", req, c.protoClient.QueryStateAlpha1)
 	if err != nil {
 		return nil, fmt.Errorf("error querying state: %w", err)
 	}
@@ -451,9 +734,13 @@ func (c *GRPCClient) DeleteState(ctx context.Context, storeName, key string, met
 
 // DeleteStateWithETag deletes content from store using provided state options and etag.
 func (c *GRPCClient) DeleteStateWithETag(ctx context.Context, storeName, key string, etag *ETag, meta map[string]string, opts *StateOptions) error {
+	storeName = c.stateStoreName(storeName)
 	if err := hasRequiredStateArgs(storeName, key); err != nil {
 		return fmt.Errorf("missing required arguments: %w", err)
 	}
+	if err := c.checkStateKeys(key); err != nil {
+		return err
+	}
 
 	req := &pb.DeleteStateRequest{
 		StoreName: storeName,
@@ -468,7 +755,8 @@ func (c *GRPCClient) DeleteStateWithETag(ctx context.Context, storeName, key str
 		}
 	}
 
-	_, err := c.protoClient.DeleteState(ctx, req)
+	_, err := callDapr(ctx, c, "DeleteState", req, c.protoClient.DeleteState)
+	c.invalidateCachedState(storeName, key)
 	if err != nil {
 		return fmt.Errorf("error deleting state: %w", err)
 	}
@@ -499,13 +787,23 @@ func (c *GRPCClient) DeleteBulkStateItems(ctx context.Context, storeName string,
 	if len(items) == 0 {
 		return nil
 	}
+	storeName = c.stateStoreName(storeName)
+	start := time.Now()
+	err := c.deleteStateItems(ctx, storeName, items)
+	c.observeBulkState(BulkStateDelete, storeName, len(items), start, err)
+	return err
+}
 
+func (c *GRPCClient) deleteStateItems(ctx context.Context, storeName string, items []*DeleteStateItem) error {
 	states := make([]*v1.StateItem, 0, len(items))
 	for i := 0; i < len(items); i++ {
 		item := items[i]
 		if err := hasRequiredStateArgs(storeName, item.Key); err != nil {
 			return fmt.Errorf("missing required arguments: %w", err)
 		}
+		if err := c.checkStateKeys(item.Key); err != nil {
+			return err
+		}
 
 		state := &v1.StateItem{
 			Key:      item.Key,
@@ -524,17 +822,47 @@ func (c *GRPCClient) DeleteBulkStateItems(ctx context.Context, storeName string,
 		StoreName: storeName,
 		States:    states,
 	}
-	_, err := c.protoClient.DeleteBulkState(ctx, req)
+	_, err := callDapr(ctx, c, "DeleteBulkState", req, c.protoClient.DeleteBulkState)
+	for _, item := range items {
+		c.invalidateCachedState(storeName, item.Key)
+	}
 
 	return err
 }
 
+// stateStoreName returns storeName, or the default state store of the client when storeName is empty.
+func (c *GRPCClient) stateStoreName(storeName string) string {
+	if storeName == "" {
+		return c.defaultStateStore
+	}
+	return storeName
+}
+
 func hasRequiredStateArgs(storeName, key string) error {
 	if storeName == "" {
-		return errors.New("store")
+		return missingComponentName("state store")
 	}
 	if key == "" {
 		return errors.New("key")
 	}
 	return nil
 }
+
+// reservedStateKeySeparators are used by the Dapr runtime to build the keys it persists,
+// such as the app ID prefix in "appid||key", and corrupt those keys when used in a key.
+var reservedStateKeySeparators = []string{"||"}
+
+// checkStateKeys rejects keys containing reserved separators when state key validation is enabled.
+func (c *GRPCClient) checkStateKeys(keys ...string) error {
+	if !c.stateKeyValidation {
+		return nil
+	}
+	for _, key := range keys {
+		for _, sep := range reservedStateKeySeparators {
+			if strings.Contains(key, sep) {
+				return fmt.Errorf("invalid state key %q: contains reserved separator %q", key, sep)
+			}
+		}
+	}
+	return nil
+}
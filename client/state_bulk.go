@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// StateChunkError reports a chunk of SaveBulkStateChunked which failed to save.
+type StateChunkError struct {
+	// Chunk is the index of the chunk, in the order the chunks were saved.
+	Chunk int
+	// Keys are the keys of the items of the chunk.
+	Keys []string
+	Err  error
+}
+
+func (e *StateChunkError) Error() string {
+	return fmt.Sprintf("error saving chunk %d (%d items): %v", e.Chunk, len(e.Keys), e.Err)
+}
+
+func (e *StateChunkError) Unwrap() error {
+	return e.Err
+}
+
+// BulkStateChunksError is returned by SaveBulkStateChunked when some chunks failed to save.
+// The items of the other chunks were saved.
+type BulkStateChunksError struct {
+	// Chunks is the total number of chunks.
+	Chunks int
+	Failed []*StateChunkError
+}
+
+func (e *BulkStateChunksError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("%d of %d chunks failed to save: %s", len(e.Failed), e.Chunks, strings.Join(msgs, "; "))
+}
+
+func (e *BulkStateChunksError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f
+	}
+	return errs
+}
+
+// SaveBulkStateChunked saves items into store in chunks whose serialized size stays under maxBytes,
+// which avoids exceeding the message size limits of the sidecar or the component.
+// Items are chunked in their order and the chunks are saved sequentially, an item larger than maxBytes
+// is saved in a chunk of its own. A failed chunk doesn't stop the following ones: the returned
+// *BulkStateChunksError reports every failed chunk and the keys of its items.
+// The options apply to the items which don't set their own Options.
+func (c *GRPCClient) SaveBulkStateChunked(ctx context.Context, storeName string, items []*SetStateItem, maxBytes int, opts ...StateOption) error {
+	storeName = c.stateStoreName(storeName)
+	if storeName == "" {
+		return missingComponentName("state store")
+	}
+	if maxBytes < 1 {
+		return fmt.Errorf("invalid chunk size %d: must be at least 1 byte", maxBytes)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	var defaults *StateOptions
+	if len(opts) > 0 {
+		defaults = new(StateOptions)
+		for _, o := range opts {
+			o(defaults)
+		}
+	}
+
+	chunks := chunkStateItems(items, maxBytes, defaults)
+	var failed []*StateChunkError
+	for i, chunk := range chunks {
+		if err := c.SaveBulkState(ctx, storeName, chunk...); err != nil {
+			keys := make([]string, len(chunk))
+			for j, item := range chunk {
+				keys[j] = item.Key
+			}
+			failed = append(failed, &StateChunkError{Chunk: i, Keys: keys, Err: err})
+		}
+	}
+	if len(failed) > 0 {
+		return &BulkStateChunksError{Chunks: len(chunks), Failed: failed}
+	}
+	return nil
+}
+
+// chunkStateItems splits items in order into chunks whose serialized size stays under maxBytes,
+// setting defaults on the items without options.
+func chunkStateItems(items []*SetStateItem, maxBytes int, defaults *StateOptions) [][]*SetStateItem {
+	var (
+		chunks [][]*SetStateItem
+		chunk  []*SetStateItem
+		size   int
+	)
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if item.Options == nil && defaults != nil {
+			withDefaults := *item
+			withDefaults.Options = defaults
+			item = &withDefaults
+		}
+		// Account for the field tag and length prefix of the item in the request.
+		itemSize := protowire.SizeTag(2) + protowire.SizeBytes(proto.Size(toProtoSaveStateItem(item)))
+		if len(chunk) > 0 && size+itemSize > maxBytes {
+			chunks = append(chunks, chunk)
+			chunk, size = nil, 0
+		}
+		chunk = append(chunk, item)
+		size += itemSize
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// chunkRecordingClient records the keys of every SaveState request and fails the requests containing failKey.
+type chunkRecordingClient struct {
+	pb.DaprClient
+	failKey  string
+	requests [][]string
+	sizes    []int
+}
+
+func (c *chunkRecordingClient) SaveState(ctx context.Context, in *pb.SaveStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	keys := make([]string, 0, len(in.GetStates()))
+	for _, item := range in.GetStates() {
+		keys = append(keys, item.GetKey())
+	}
+	c.requests = append(c.requests, keys)
+	c.sizes = append(c.sizes, proto.Size(&pb.SaveStateRequest{States: in.GetStates()}))
+	for _, k := range keys {
+		if k == c.failKey {
+			return nil, errors.New("message too large")
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestSaveBulkStateChunked$
+func TestSaveBulkStateChunked(t *testing.T) {
+	ctx := context.Background()
+	items := make([]*SetStateItem, 10)
+	for i := range items {
+		items[i] = &SetStateItem{Key: fmt.Sprintf("key%d", i), Value: []byte(strings.Repeat("x", 100))}
+	}
+
+	t.Run("items are split into ordered chunks under the limit", func(t *testing.T) {
+		fake := &chunkRecordingClient{}
+		c := &GRPCClient{protoClient: fake}
+		require.NoError(t, c.SaveBulkStateChunked(ctx, testStore, items, 400))
+
+		require.GreaterOrEqual(t, len(fake.requests), 2)
+		saved := make([]string, 0, len(items))
+		for i, keys := range fake.requests {
+			assert.LessOrEqual(t, fake.sizes[i], 400)
+			saved = append(saved, keys...)
+		}
+		expected := make([]string, len(items))
+		for i, item := range items {
+			expected[i] = item.Key
+		}
+		assert.Equal(t, expected, saved)
+	})
+
+	t.Run("failed chunks are reported", func(t *testing.T) {
+		fake := &chunkRecordingClient{failKey: "key4"}
+		c := &GRPCClient{protoClient: fake}
+		err := c.SaveBulkStateChunked(ctx, testStore, items, 400)
+
+		var chunksErr *BulkStateChunksError
+		require.ErrorAs(t, err, &chunksErr)
+		assert.Equal(t, len(fake.requests), chunksErr.Chunks)
+		require.Len(t, chunksErr.Failed, 1)
+		failed := chunksErr.Failed[0]
+		assert.Contains(t, failed.Keys, "key4")
+		assert.Equal(t, fake.requests[failed.Chunk], failed.Keys)
+		assert.ErrorContains(t, err, "message too large")
+		assert.Len(t, fake.requests, chunksErr.Chunks, "chunks after the failed one are still saved")
+	})
+
+	t.Run("oversized item is saved alone", func(t *testing.T) {
+		fake := &chunkRecordingClient{}
+		c := &GRPCClient{protoClient: fake}
+		large := []*SetStateItem{
+			{Key: "small", Value: []byte("x")},
+			{Key: "large", Value: []byte(strings.Repeat("x", 1000))},
+			{Key: "small2", Value: []byte("x")},
+		}
+		require.NoError(t, c.SaveBulkStateChunked(ctx, testStore, large, 100))
+		assert.Equal(t, [][]string{{"small"}, {"large"}, {"small2"}}, fake.requests)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &chunkRecordingClient{}}
+		require.ErrorIs(t, c.SaveBulkStateChunked(ctx, "", items, 400), ErrMissingComponentName)
+		require.Error(t, c.SaveBulkStateChunked(ctx, testStore, items, 0))
+	})
+}
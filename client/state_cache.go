@@ -0,0 +1,291 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+)
+
+const stateCacheMaxEntriesDefault = 10000
+
+// CacheConfig configures the client-side state cache enabled with WithStateCache.
+type CacheConfig struct {
+	// TTL is how long a retrieved item is served from the cache without contacting the sidecar.
+	TTL time.Duration
+	// StaleWhileRevalidate is how long after TTL an item is still served from the cache
+	// while it is refreshed in the background.
+	StaleWhileRevalidate time.Duration
+	// MaxEntries is the maximum number of cached items, the least recently used items are evicted
+	// beyond it. It also bounds the number of keys with access statistics. Defaults to 10000.
+	MaxEntries int
+}
+
+// WithStateCache caches the items retrieved with GetState and GetStateWithConsistency, which is
+// useful for read-heavy hot keys. Only calls without metadata are cached, and items are invalidated
+// when written or deleted through the same client. Writes by other clients are observed once the
+// cached item expires, the configured consistency is not guaranteed for cached reads.
+// Concurrent retrievals of the same key share a single call to the sidecar.
+func WithStateCache(config CacheConfig) ClientOption {
+	return func(o *clientOptions) {
+		if config.TTL <= 0 {
+			o.errs = append(o.errs, errors.New("state cache TTL must be positive"))
+		}
+		if config.StaleWhileRevalidate < 0 {
+			o.errs = append(o.errs, errors.New("state cache stale-while-revalidate window must not be negative"))
+		}
+		if config.MaxEntries < 0 {
+			o.errs = append(o.errs, errors.New("state cache max entries must not be negative"))
+		}
+		o.stateCache = &config
+	}
+}
+
+type stateCacheEntry struct {
+	key     string
+	item    *StateItem
+	fetched time.Time
+}
+
+type stateCacheCall struct {
+	done        chan struct{}
+	item        *StateItem
+	err         error
+	invalidated bool
+}
+
+// StateCacheKeyStats are the access statistics of a key of the state cache.
+type StateCacheKeyStats struct {
+	StoreName string
+	Key       string
+	// Hits is the number of retrievals served from the cache, including stale items being revalidated.
+	Hits uint64
+	// Misses is the number of retrievals which waited for the sidecar.
+	Misses uint64
+	// LastAccess is the time of the last retrieval of the key.
+	LastAccess time.Time
+	// FetchLatency is the duration of the last retrieval of the key from the sidecar.
+	FetchLatency time.Duration
+}
+
+// StateCacheStats are the access statistics of the state cache.
+type StateCacheStats struct {
+	// Hits and Misses are the totals since the client was created.
+	Hits   uint64
+	Misses uint64
+	// Keys holds the statistics of the most recently retrieved keys, up to MaxEntries keys,
+	// ordered from the most to the least accessed key.
+	Keys []StateCacheKeyStats
+}
+
+type stateCache struct {
+	config CacheConfig
+	now    func() time.Time
+
+	mu sync.Mutex
+	// entries and stats index the elements of lru and statsLRU, which are ordered from the most
+	// to the least recently used key.
+	entries  map[string]*list.Element
+	lru      *list.List
+	calls    map[string]*stateCacheCall
+	stats    map[string]*list.Element
+	statsLRU *list.List
+	hits     uint64
+	misses   uint64
+}
+
+func newStateCache(config CacheConfig) *stateCache {
+	if config.MaxEntries == 0 {
+		config.MaxEntries = stateCacheMaxEntriesDefault
+	}
+	return &stateCache{
+		config:   config,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		calls:    make(map[string]*stateCacheCall),
+		stats:    make(map[string]*list.Element),
+		statsLRU: list.New(),
+	}
+}
+
+func stateCacheKey(storeName, key string) string {
+	return CompositeKey{parts: []string{storeName, key}}.String()
+}
+
+// get returns the cached item for the key of storeName, calling fetch when it is missing or expired.
+// Stale items within the revalidation window are returned immediately and refreshed in the background.
+func (sc *stateCache) get(ctx context.Context, storeName, key string, fetch func(context.Context) (*StateItem, error)) (*StateItem, error) {
+	cacheKey := stateCacheKey(storeName, key)
+	sc.mu.Lock()
+	now := sc.now()
+	stats := sc.statsLocked(cacheKey, storeName, key)
+	stats.LastAccess = now
+	if el, ok := sc.entries[cacheKey]; ok {
+		e := el.Value.(*stateCacheEntry)
+		age := now.Sub(e.fetched)
+		if age < sc.config.TTL+sc.config.StaleWhileRevalidate {
+			stats.Hits++
+			sc.hits++
+			sc.lru.MoveToFront(el)
+			if age >= sc.config.TTL {
+				sc.startLocked(ctx, cacheKey, fetch)
+			}
+			sc.mu.Unlock()
+			return cloneStateItem(e.item), nil
+		}
+		sc.removeLocked(cacheKey)
+	}
+	stats.Misses++
+	sc.misses++
+	call := sc.startLocked(ctx, cacheKey, fetch)
+	sc.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	return cloneStateItem(call.item), nil
+}
+
+// startLocked starts fetching key unless a fetch is already in flight, sc.mu must be held.
+// The fetch is shared by all callers, so it isn't cancelled with the context of the first one.
+func (sc *stateCache) startLocked(ctx context.Context, key string, fetch func(context.Context) (*StateItem, error)) *stateCacheCall {
+	if call, ok := sc.calls[key]; ok {
+		return call
+	}
+	call := &stateCacheCall{done: make(chan struct{})}
+	sc.calls[key] = call
+	go func() {
+		start := time.Now()
+		item, err := fetch(context.WithoutCancel(ctx))
+		latency := time.Since(start)
+		sc.mu.Lock()
+		call.item, call.err = item, err
+		if el, ok := sc.stats[key]; ok {
+			el.Value.(*StateCacheKeyStats).FetchLatency = latency
+		}
+		// An invalidated call was replaced by the fetch started after the write, if any.
+		if sc.calls[key] == call {
+			delete(sc.calls, key)
+		}
+		if err == nil && !call.invalidated {
+			sc.storeLocked(key, item)
+		}
+		sc.mu.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+// storeLocked caches item as the latest value of key and evicts the least recently used items
+// beyond the maximum number of entries, sc.mu must be held.
+func (sc *stateCache) storeLocked(key string, item *StateItem) {
+	sc.removeLocked(key)
+	sc.entries[key] = sc.lru.PushFront(&stateCacheEntry{key: key, item: item, fetched: sc.now()})
+	for sc.lru.Len() > sc.config.MaxEntries {
+		sc.removeLocked(sc.lru.Back().Value.(*stateCacheEntry).key)
+	}
+}
+
+// removeLocked removes the cached item of key, sc.mu must be held.
+func (sc *stateCache) removeLocked(key string) {
+	if el, ok := sc.entries[key]; ok {
+		sc.lru.Remove(el)
+		delete(sc.entries, key)
+	}
+}
+
+// statsLocked returns the statistics of cacheKey and marks them as the most recently used,
+// dropping the statistics of the least recently retrieved keys beyond the maximum number of
+// entries, sc.mu must be held.
+func (sc *stateCache) statsLocked(cacheKey, storeName, key string) *StateCacheKeyStats {
+	if el, ok := sc.stats[cacheKey]; ok {
+		sc.statsLRU.MoveToFront(el)
+		return el.Value.(*StateCacheKeyStats)
+	}
+	stats := &StateCacheKeyStats{StoreName: storeName, Key: key}
+	sc.stats[cacheKey] = sc.statsLRU.PushFront(stats)
+	for sc.statsLRU.Len() > sc.config.MaxEntries {
+		oldest := sc.statsLRU.Remove(sc.statsLRU.Back()).(*StateCacheKeyStats)
+		delete(sc.stats, stateCacheKey(oldest.StoreName, oldest.Key))
+	}
+	return stats
+}
+
+// snapshot returns a copy of the cache statistics.
+func (sc *stateCache) snapshot() StateCacheStats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	out := StateCacheStats{Hits: sc.hits, Misses: sc.misses, Keys: make([]StateCacheKeyStats, 0, len(sc.stats))}
+	for el := sc.statsLRU.Front(); el != nil; el = el.Next() {
+		out.Keys = append(out.Keys, *el.Value.(*StateCacheKeyStats))
+	}
+	sort.Slice(out.Keys, func(i, j int) bool {
+		a, b := out.Keys[i], out.Keys[j]
+		if a.Hits+a.Misses != b.Hits+b.Misses {
+			return a.Hits+a.Misses > b.Hits+b.Misses
+		}
+		return stateCacheKey(a.StoreName, a.Key) < stateCacheKey(b.StoreName, b.Key)
+	})
+	return out
+}
+
+// invalidate removes key from the cache and discards the result of an in-flight fetch,
+// the retrievals following the invalidation start a new fetch rather than joining it.
+func (sc *stateCache) invalidate(key string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.removeLocked(key)
+	if call, ok := sc.calls[key]; ok {
+		call.invalidated = true
+		delete(sc.calls, key)
+	}
+}
+
+func cloneStateItem(item *StateItem) *StateItem {
+	clone := *item
+	clone.Value = bytes.Clone(item.Value)
+	clone.Metadata = maps.Clone(item.Metadata)
+	return &clone
+}
+
+// invalidateCachedState removes the keys of storeName from the state cache, if enabled.
+func (c *GRPCClient) invalidateCachedState(storeName string, keys ...string) {
+	if c.stateCache == nil {
+		return
+	}
+	for _, key := range keys {
+		c.stateCache.invalidate(stateCacheKey(storeName, key))
+	}
+}
+
+// StateCacheStats returns the access statistics of the state cache, which are useful to identify hot keys
+// and to tune the cache configuration. The statistics are empty when the cache isn't enabled with WithStateCache.
+func (c *GRPCClient) StateCacheStats() StateCacheStats {
+	if c.stateCache == nil {
+		return StateCacheStats{}
+	}
+	return c.stateCache.snapshot()
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+type countingStateClient struct {
+	pb.DaprClient
+	calls   atomic.Int32
+	value   atomic.Value
+	release chan struct{}
+}
+
+func newCountingStateClient(value string) *countingStateClient {
+	c := &countingStateClient{}
+	c.value.Store(value)
+	return c
+}
+
+func (c *countingStateClient) GetState(ctx context.Context, in *pb.GetStateRequest, opts ...grpc.CallOption) (*pb.GetStateResponse, error) {
+	c.calls.Add(1)
+	if c.release != nil {
+		<-c.release
+	}
+	return &pb.GetStateResponse{Data: []byte(c.value.Load().(string))}, nil
+}
+
+func (c *countingStateClient) SaveState(ctx context.Context, in *pb.SaveStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.value.Store(string(in.GetStates()[0].GetValue()))
+	return &emptypb.Empty{}, nil
+}
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newCachingTestClient(proto pb.DaprClient, clock *testClock) *GRPCClient {
+	sc := newStateCache(CacheConfig{TTL: time.Minute, StaleWhileRevalidate: time.Minute})
+	sc.now = clock.Now
+	return &GRPCClient{protoClient: proto, stateCache: sc}
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestStateCache$
+func TestStateCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fresh item is served from the cache", func(t *testing.T) {
+		proto := newCountingStateClient("v1")
+		c := newCachingTestClient(proto, &testClock{now: time.Now()})
+		for i := 0; i < 3; i++ {
+			item, err := c.GetState(ctx, testStore, "key1", nil)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("v1"), item.Value)
+		}
+		assert.Equal(t, int32(1), proto.calls.Load())
+
+		_, err := c.GetState(ctx, testStore, "key1", map[string]string{"partitionKey": "p1"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), proto.calls.Load())
+	})
+
+	t.Run("stale item is served while revalidating", func(t *testing.T) {
+		proto := newCountingStateClient("v1")
+		clock := &testClock{now: time.Now()}
+		c := newCachingTestClient(proto, clock)
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+
+		proto.value.Store("v2")
+		clock.Advance(90 * time.Second)
+		item, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), item.Value)
+
+		assert.Eventually(t, func() bool {
+			item, err := c.GetState(ctx, testStore, "key1", nil)
+			return err == nil && string(item.Value) == "v2"
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(2), proto.calls.Load())
+	})
+
+	t.Run("expired item is fetched", func(t *testing.T) {
+		proto := newCountingStateClient("v1")
+		clock := &testClock{now: time.Now()}
+		c := newCachingTestClient(proto, clock)
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+
+		proto.value.Store("v2")
+		clock.Advance(3 * time.Minute)
+		item, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), item.Value)
+	})
+
+	t.Run("concurrent misses share a single call", func(t *testing.T) {
+		proto := newCountingStateClient("v1")
+		proto.release = make(chan struct{})
+		c := newCachingTestClient(proto, &testClock{now: time.Now()})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				item, err := c.GetState(ctx, testStore, "key1", nil)
+				assert.NoError(t, err)
+				assert.Equal(t, []byte("v1"), item.Value)
+			}()
+		}
+		assert.Eventually(t, func() bool {
+			return proto.calls.Load() == 1
+		}, time.Second, 10*time.Millisecond)
+		close(proto.release)
+		wg.Wait()
+		assert.Equal(t, int32(1), proto.calls.Load())
+	})
+
+	t.Run("writes invalidate the cached item", func(t *testing.T) {
+		proto := newCountingStateClient("v1")
+		c := newCachingTestClient(proto, &testClock{now: time.Now()})
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+
+		require.NoError(t, c.SaveState(ctx, testStore, "key1", []byte("v2"), nil))
+		item, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), item.Value)
+	})
+
+	t.Run("reads after a write don't join the fetch in flight", func(t *testing.T) {
+		proto := newCountingStateClient("v1")
+		proto.release = make(chan struct{})
+		c := newCachingTestClient(proto, &testClock{now: time.Now()})
+
+		stale := make(chan *StateItem, 1)
+		go func() {
+			item, err := c.GetState(ctx, testStore, "key1", nil)
+			assert.NoError(t, err)
+			stale <- item
+		}()
+		require.Eventually(t, func() bool {
+			return proto.calls.Load() == 1
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, c.SaveState(ctx, testStore, "key1", []byte("v2"), nil))
+		fresh := make(chan *StateItem, 1)
+		go func() {
+			item, err := c.GetState(ctx, testStore, "key1", nil)
+			assert.NoError(t, err)
+			fresh <- item
+		}()
+		require.Eventually(t, func() bool {
+			return proto.calls.Load() == 2
+		}, time.Second, time.Millisecond)
+		close(proto.release)
+
+		<-stale
+		assert.Equal(t, []byte("v2"), (<-fresh).Value)
+		item, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), item.Value)
+		assert.Equal(t, int32(2), proto.calls.Load())
+	})
+
+	t.Run("stats count hits and misses", func(t *testing.T) {
+		proto := newCountingStateClient("v1")
+		clock := &testClock{now: time.Now()}
+		c := newCachingTestClient(proto, clock)
+		for i := 0; i < 3; i++ {
+			_, err := c.GetState(ctx, testStore, "hot", nil)
+			require.NoError(t, err)
+		}
+		_, err := c.GetState(ctx, testStore, "cold", nil)
+		require.NoError(t, err)
+		clock.Advance(3 * time.Minute)
+		_, err = c.GetState(ctx, testStore, "cold", nil)
+		require.NoError(t, err)
+		_, err = c.GetState(ctx, testStore, "uncached", map[string]string{"partitionKey": "p1"})
+		require.NoError(t, err)
+
+		stats := c.StateCacheStats()
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(3), stats.Misses)
+		require.Len(t, stats.Keys, 2)
+		assert.Equal(t, StateCacheKeyStats{StoreName: testStore, Key: "hot", Hits: 2, Misses: 1, LastAccess: clock.now.Add(-3 * time.Minute), FetchLatency: stats.Keys[0].FetchLatency}, stats.Keys[0])
+		assert.Equal(t, "cold", stats.Keys[1].Key)
+		assert.Equal(t, uint64(0), stats.Keys[1].Hits)
+		assert.Equal(t, uint64(2), stats.Keys[1].Misses)
+		assert.Equal(t, clock.Now(), stats.Keys[1].LastAccess)
+		assert.Positive(t, stats.Keys[1].FetchLatency)
+
+		assert.Empty(t, (&GRPCClient{}).StateCacheStats().Keys)
+	})
+
+	t.Run("least recently used items are evicted", func(t *testing.T) {
+		proto := newCountingStateClient("v1")
+		c := newCachingTestClient(proto, &testClock{now: time.Now()})
+		c.stateCache.config.MaxEntries = 2
+		for _, key := range []string{"key1", "key2", "key1", "key3"} {
+			_, err := c.GetState(ctx, testStore, key, nil)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), proto.calls.Load())
+		assert.Len(t, c.stateCache.entries, 2)
+
+		// key2 was evicted, key1 was used since and is still cached.
+		_, err := c.GetState(ctx, testStore, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), proto.calls.Load())
+		_, err = c.GetState(ctx, testStore, "key2", nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(4), proto.calls.Load())
+
+		stats := c.StateCacheStats()
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(4), stats.Misses)
+		require.Len(t, stats.Keys, 2)
+		assert.ElementsMatch(t, []string{"key1", "key2"}, []string{stats.Keys[0].Key, stats.Keys[1].Key})
+	})
+
+	t.Run("cached items are copies", func(t *testing.T) {
+		sc := newStateCache(CacheConfig{TTL: time.Minute})
+		fetch := func(context.Context) (*StateItem, error) {
+			return &StateItem{Key: "key1", Value: []byte("v1"), Metadata: map[string]string{"m": "1"}}, nil
+		}
+		item, err := sc.get(ctx, testStore, "key1", fetch)
+		require.NoError(t, err)
+		item.Value[0] = 'x'
+		item.Metadata["m"] = "2"
+
+		item, err = sc.get(ctx, testStore, "key1", fetch)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), item.Value)
+		assert.Equal(t, map[string]string{"m": "1"}, item.Metadata)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewClientWithOptions(ctx, WithPort("50001"), WithStateCache(CacheConfig{}))
+		require.Error(t, err)
+		_, err = NewClientWithOptions(ctx, WithPort("50001"), WithStateCache(CacheConfig{TTL: time.Second, MaxEntries: -1}))
+		require.Error(t, err)
+	})
+}
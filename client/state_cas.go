@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// CompareAndSwapState writes newValue to key only if its current value equals expected, and reports
+// whether the value was swapped. The value is read with its ETag and written back with first-write
+// concurrency, so a concurrent write between the read and the write also reports false.
+// A nil or empty expected value matches a missing key, the swap then creates it; whether the concurrent
+// creation of a missing key is detected depends on the state store since it has no ETag.
+// The value is read from the store, bypassing the state cache.
+func (c *GRPCClient) CompareAndSwapState(ctx context.Context, storeName, key string, expected, newValue []byte, opts ...StateOption) (bool, error) {
+	storeName = c.stateStoreName(storeName)
+	if err := hasRequiredStateArgs(storeName, key); err != nil {
+		return false, fmt.Errorf("missing required arguments: %w", err)
+	}
+	if err := c.checkStateKeys(key); err != nil {
+		return false, err
+	}
+	current, err := c.getState(ctx, storeName, key, nil, StateConsistencyStrong)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(current.Value, expected) {
+		return false, nil
+	}
+	opts = append(opts, WithConcurrency(StateConcurrencyFirstWrite))
+	err = c.SaveStateWithETag(ctx, storeName, key, newValue, current.Etag, nil, opts...)
+	if err == nil {
+		return true, nil
+	}
+	if ClassifyStateError(err) == StateErrorConflict {
+		if c.stateConflicts != nil {
+			c.stateConflicts.conflicts.Add(1)
+		}
+		return false, nil
+	}
+	return false, err
+}
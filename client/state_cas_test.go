@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// casStateClient adds single key reads and ETag checked writes to etagStateClient.
+type casStateClient struct {
+	*etagStateClient
+	// beforeSave runs before every save, which lets tests simulate concurrent writers.
+	beforeSave func(s *etagStateClient)
+}
+
+func (c *casStateClient) GetState(ctx context.Context, in *pb.GetStateRequest, opts ...grpc.CallOption) (*pb.GetStateResponse, error) {
+	resp := &pb.GetStateResponse{}
+	if v, ok := c.state[in.GetKey()]; ok {
+		resp.Data = v.data
+		resp.Etag = strconv.Itoa(v.etag)
+	}
+	return resp, nil
+}
+
+func (c *casStateClient) SaveState(ctx context.Context, in *pb.SaveStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	if c.beforeSave != nil {
+		c.beforeSave(c.etagStateClient)
+	}
+	for _, item := range in.GetStates() {
+		current, exists := c.state[item.GetKey()]
+		if etag := item.GetEtag().GetValue(); etag != "" && (!exists || etag != strconv.Itoa(current.etag)) {
+			return nil, status.Error(codes.Aborted, "possible etag mismatch")
+		}
+		c.set(item.GetKey(), item.GetValue())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestCompareAndSwapState$
+func TestCompareAndSwapState(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("swap", func(t *testing.T) {
+		fake := &casStateClient{etagStateClient: newEtagStateClient()}
+		c := &GRPCClient{protoClient: fake}
+		swapped, err := c.CompareAndSwapState(ctx, testStore, "from", []byte("10"), []byte("9"))
+		require.NoError(t, err)
+		assert.True(t, swapped)
+		assert.Equal(t, []byte("9"), fake.state["from"].data)
+	})
+
+	t.Run("value mismatch", func(t *testing.T) {
+		fake := &casStateClient{etagStateClient: newEtagStateClient()}
+		c := &GRPCClient{protoClient: fake}
+		swapped, err := c.CompareAndSwapState(ctx, testStore, "from", []byte("5"), []byte("4"))
+		require.NoError(t, err)
+		assert.False(t, swapped)
+		assert.Equal(t, []byte("10"), fake.state["from"].data)
+	})
+
+	t.Run("concurrent write", func(t *testing.T) {
+		fake := &casStateClient{etagStateClient: newEtagStateClient()}
+		fake.beforeSave = func(s *etagStateClient) {
+			s.set("from", []byte("10"))
+		}
+		c := &GRPCClient{protoClient: fake, stateConflicts: &stateConflictCounters{}}
+		swapped, err := c.CompareAndSwapState(ctx, testStore, "from", []byte("10"), []byte("9"))
+		require.NoError(t, err)
+		assert.False(t, swapped)
+		assert.Equal(t, uint64(1), c.StateConflictStats().Conflicts)
+	})
+
+	t.Run("create missing key", func(t *testing.T) {
+		fake := &casStateClient{etagStateClient: newEtagStateClient()}
+		c := &GRPCClient{protoClient: fake}
+		swapped, err := c.CompareAndSwapState(ctx, testStore, "new", nil, []byte("1"))
+		require.NoError(t, err)
+		assert.True(t, swapped)
+		assert.Equal(t, []byte("1"), fake.state["new"].data)
+
+		swapped, err = c.CompareAndSwapState(ctx, testStore, "new", nil, []byte("2"))
+		require.NoError(t, err)
+		assert.False(t, swapped)
+		assert.Equal(t, []byte("1"), fake.state["new"].data)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &casStateClient{etagStateClient: newEtagStateClient()}}
+		_, err := c.CompareAndSwapState(ctx, "", "from", nil, []byte("1"))
+		require.ErrorIs(t, err, ErrMissingComponentName)
+		_, err = c.CompareAndSwapState(ctx, testStore, "", nil, []byte("1"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec serializes the values of the typed state helpers.
+type Codec interface {
+	// Marshal encodes v.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes data into the value pointed to by v.
+	Unmarshal(data []byte, v any) error
+	// ContentType is the content type recorded in the metadata of saved items.
+	ContentType() string
+}
+
+var (
+	// JSONCodec serializes values with encoding/json, it is the default codec of the typed state helpers.
+	JSONCodec Codec = jsonCodec{}
+	// ProtobufCodec serializes proto.Message values in the protobuf binary format.
+	ProtobufCodec Codec = protobufCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf codec: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal accepts a proto.Message or a pointer to one, as GetStateAs of a message type passes.
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, m)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && rv.Elem().Kind() == reflect.Pointer {
+		if rv.Elem().IsNil() {
+			rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+		}
+		if m, ok := rv.Elem().Interface().(proto.Message); ok {
+			return proto.Unmarshal(data, m)
+		}
+	}
+	return fmt.Errorf("protobuf codec: %T is not a proto.Message", v)
+}
+
+func (protobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+// TypedStateOption configures the typed state helpers.
+type TypedStateOption func(*typedStateOptions)
+
+type typedStateOptions struct {
+	codec Codec
+}
+
+// WithStateCodec serializes values with codec instead of JSON.
+func WithStateCodec(codec Codec) TypedStateOption {
+	return func(o *typedStateOptions) {
+		o.codec = codec
+	}
+}
+
+func newTypedStateOptions(opts []TypedStateOption) *typedStateOptions {
+	o := &typedStateOptions{codec: JSONCodec}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// SaveStateAs encodes value with the configured codec, JSON by default, and saves it into store.
+// The content type of the codec is recorded in the item metadata.
+func SaveStateAs[T any](ctx context.Context, c Client, storeName, key string, value T, opts ...TypedStateOption) error {
+	o := newTypedStateOptions(opts)
+	data, err := o.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error encoding state: %w", err)
+	}
+	meta := map[string]string{stateMetadataKeyContentType: o.codec.ContentType()}
+	return c.SaveState(ctx, storeName, key, data, meta)
+}
+
+// GetStateAs retrieves the state of key and decodes it with the configured codec, JSON by default.
+// The zero value of T is returned when the key doesn't exist.
+func GetStateAs[T any](ctx context.Context, c Client, storeName, key string, opts ...TypedStateOption) (T, error) {
+	o := newTypedStateOptions(opts)
+	var v T
+	item, err := c.GetState(ctx, storeName, key, nil)
+	if err != nil {
+		return v, err
+	}
+	if item == nil || len(item.Value) == 0 {
+		return v, nil
+	}
+	if err := o.codec.Unmarshal(item.Value, &v); err != nil {
+		return v, fmt.Errorf("error decoding state: %w", err)
+	}
+	return v, nil
+}
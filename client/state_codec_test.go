@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// go test -timeout 30s ./client -count 1 -run ^TestStateCodecs$
+func TestStateCodecs(t *testing.T) {
+	ctx := context.Background()
+	t.Cleanup(func() {
+		require.NoError(t, testClient.DeleteBulkState(ctx, testStore, []string{"codec-json", "codec-proto"}, nil))
+	})
+
+	t.Run("json round trip", func(t *testing.T) {
+		type order struct {
+			ID    string  `json:"id"`
+			Total float64 `json:"total"`
+		}
+		in := order{ID: "o1", Total: 9.5}
+		require.NoError(t, SaveStateAs(ctx, testClient, testStore, "codec-json", in))
+		out, err := GetStateAs[order](ctx, testClient, testStore, "codec-json")
+		require.NoError(t, err)
+		assert.Equal(t, in, out)
+	})
+
+	t.Run("protobuf round trip", func(t *testing.T) {
+		in := wrapperspb.String("hello")
+		require.NoError(t, SaveStateAs(ctx, testClient, testStore, "codec-proto", in, WithStateCodec(ProtobufCodec)))
+
+		item, err := testClient.GetState(ctx, testStore, "codec-proto", nil)
+		require.NoError(t, err)
+		raw, err := proto.Marshal(in)
+		require.NoError(t, err)
+		assert.Equal(t, raw, item.Value)
+
+		out, err := GetStateAs[*wrapperspb.StringValue](ctx, testClient, testStore, "codec-proto", WithStateCodec(ProtobufCodec))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(in, out))
+
+		values, errs := GetBulkStateAs[*wrapperspb.StringValue](ctx, testClient, testStore, []string{"codec-proto"}, 1, WithStateCodec(ProtobufCodec))
+		assert.Empty(t, errs)
+		assert.True(t, proto.Equal(in, values["codec-proto"]))
+	})
+
+	t.Run("protobuf codec rejects non messages", func(t *testing.T) {
+		err := SaveStateAs(ctx, testClient, testStore, "codec-proto", "text", WithStateCodec(ProtobufCodec))
+		require.Error(t, err)
+		_, err = GetStateAs[string](ctx, testClient, testStore, "codec-proto", WithStateCodec(ProtobufCodec))
+		require.Error(t, err)
+	})
+
+	t.Run("missing key returns zero value", func(t *testing.T) {
+		out, err := GetStateAs[*wrapperspb.StringValue](ctx, testClient, testStore, "codec-missing", WithStateCodec(ProtobufCodec))
+		require.NoError(t, err)
+		assert.Nil(t, out)
+	})
+
+	t.Run("content types", func(t *testing.T) {
+		assert.Equal(t, "application/json", JSONCodec.ContentType())
+		assert.Equal(t, "application/x-protobuf", ProtobufCodec.ContentType())
+	})
+}
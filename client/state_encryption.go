@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+
+	v1 "github.com/dapr/dapr/pkg/proto/common/v1"
+)
+
+const (
+	// stateEncryptionHeader starts every document produced by the Dapr encryption scheme.
+	stateEncryptionHeader = "dapr.io/enc/v1\n"
+
+	stateEncryptionKeyWrapAlgorithmDefault = "A256KW"
+
+	stateMetadataKeyCryptoComponent = "cryptoComponent"
+	stateMetadataKeyCryptoKeyName   = "cryptoKeyName"
+
+	// StateMetadataKeyEncrypted marks a saved item whose value is already encrypted with the Dapr
+	// encryption scheme, such as a value returned by QueryStateAlpha1, when set to "true".
+	// With WithStateEncryption, marked values starting with the header of the scheme are saved as is.
+	// The marker isn't sent to the state store.
+	StateMetadataKeyEncrypted = "cryptoEncrypted"
+)
+
+// ErrStateNotEncrypted is returned when reading a state value which isn't encrypted while state encryption is enabled.
+var ErrStateNotEncrypted = errors.New("state value isn't encrypted")
+
+// StateEncryptionOption configures the state encryption enabled with WithStateEncryption.
+type StateEncryptionOption func(*stateEncryption)
+
+type stateEncryption struct {
+	component      string
+	keyName        string
+	algorithm      string
+	allowPlaintext bool
+}
+
+// WithStateKeyWrapAlgorithm sets the algorithm wrapping the data encryption key, defaults to A256KW.
+func WithStateKeyWrapAlgorithm(algorithm string) StateEncryptionOption {
+	return func(e *stateEncryption) {
+		e.algorithm = algorithm
+	}
+}
+
+// WithStateEncryptionAllowPlaintext returns the values which aren't encrypted as is instead of
+// failing with ErrStateNotEncrypted, so items saved before encryption was enabled remain readable
+// while they are migrated.
+func WithStateEncryptionAllowPlaintext() StateEncryptionOption {
+	return func(e *stateEncryption) {
+		e.allowPlaintext = true
+	}
+}
+
+// WithStateEncryption encrypts state values with the key keyName of the crypto component cryptoComponent
+// before they are sent to the state store, and decrypts them when retrieved.
+// It applies to SaveState, SaveBulkState, the upserts of ExecuteStateTransaction, GetState and GetBulkState,
+// values returned by QueryStateAlpha1 are left encrypted. Every non-empty value is encrypted, unless its item
+// is marked with StateMetadataKeyEncrypted: a value merely starting with the header of the Dapr encryption
+// scheme is encrypted like any other. Retrieving a value which isn't encrypted
+// fails with ErrStateNotEncrypted, unless WithStateEncryptionAllowPlaintext is set.
+// Decrypted items have the crypto component and key name recorded in their metadata.
+func WithStateEncryption(cryptoComponent, keyName string, opts ...StateEncryptionOption) ClientOption {
+	return func(o *clientOptions) {
+		if cryptoComponent == "" {
+			o.errs = append(o.errs, errors.New("state encryption requires a crypto component"))
+		}
+		if keyName == "" {
+			o.errs = append(o.errs, errors.New("state encryption requires a key name"))
+		}
+		e := &stateEncryption{
+			component: cryptoComponent,
+			keyName:   keyName,
+			algorithm: stateEncryptionKeyWrapAlgorithmDefault,
+		}
+		for _, opt := range opts {
+			opt(e)
+		}
+		o.stateEncryption = e
+	}
+}
+
+func isStateEncrypted(value []byte) bool {
+	return bytes.HasPrefix(value, []byte(stateEncryptionHeader))
+}
+
+// encryptStateItem encrypts the value of item when state encryption is enabled and the value isn't empty,
+// nor marked as already encrypted with StateMetadataKeyEncrypted. The marker is removed from the metadata.
+func (c *GRPCClient) encryptStateItem(ctx context.Context, item *v1.StateItem) error {
+	if c.stateEncryption == nil {
+		return nil
+	}
+	marked := false
+	if v, ok := item.GetMetadata()[StateMetadataKeyEncrypted]; ok {
+		marked = v == "true"
+		// The metadata may be owned by the caller, so the marker is removed from a copy.
+		item.Metadata = maps.Clone(item.GetMetadata())
+		delete(item.Metadata, StateMetadataKeyEncrypted)
+	}
+	if len(item.GetValue()) == 0 || (marked && isStateEncrypted(item.GetValue())) {
+		return nil
+	}
+	value, err := c.encryptStateValue(ctx, item.GetValue())
+	if err != nil {
+		return err
+	}
+	item.Value = value
+	return nil
+}
+
+// encryptStateValue encrypts value with the configured key.
+func (c *GRPCClient) encryptStateValue(ctx context.Context, value []byte) ([]byte, error) {
+	out, err := c.Encrypt(ctx, bytes.NewReader(value), EncryptOptions{
+		ComponentName:    c.stateEncryption.component,
+		KeyName:          c.stateEncryption.keyName,
+		KeyWrapAlgorithm: c.stateEncryption.algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting state: %w", err)
+	}
+	encrypted, err := io.ReadAll(out)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting state: %w", err)
+	}
+	return encrypted, nil
+}
+
+// decryptStateValue decrypts value when state encryption is enabled and value isn't empty,
+// returning the metadata with the crypto details recorded.
+func (c *GRPCClient) decryptStateValue(ctx context.Context, value []byte, meta map[string]string) ([]byte, map[string]string, error) {
+	if c.stateEncryption == nil || len(value) == 0 {
+		return value, meta, nil
+	}
+	if !isStateEncrypted(value) {
+		if c.stateEncryption.allowPlaintext {
+			return value, meta, nil
+		}
+		return nil, nil, fmt.Errorf("error decrypting state: %w", ErrStateNotEncrypted)
+	}
+	out, err := c.Decrypt(ctx, bytes.NewReader(value), DecryptOptions{
+		ComponentName: c.stateEncryption.component,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error decrypting state: %w", err)
+	}
+	decrypted, err := io.ReadAll(out)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error decrypting state: %w", err)
+	}
+	meta = maps.Clone(meta)
+	if meta == nil {
+		meta = make(map[string]string, 2)
+	}
+	meta[stateMetadataKeyCryptoComponent] = c.stateEncryption.component
+	meta[stateMetadataKeyCryptoKeyName] = c.stateEncryption.keyName
+	return decrypted, meta, nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// envelopeCryptoServer is a crypto component which "encrypts" by prefixing the Dapr encryption
+// header and reversing the data, so encrypted values differ from their plaintext.
+type envelopeCryptoServer struct {
+	*testDaprServer
+}
+
+func (s *envelopeCryptoServer) EncryptAlpha1(stream pb.Dapr_EncryptAlpha1Server) error {
+	data, err := recvCryptoPayload(stream, &pb.EncryptRequest{})
+	if err != nil {
+		return err
+	}
+	out := append([]byte(stateEncryptionHeader), reverseBytes(data)...)
+	return stream.Send(&pb.EncryptResponse{Payload: &commonv1pb.StreamPayload{Data: out}})
+}
+
+func (s *envelopeCryptoServer) DecryptAlpha1(stream pb.Dapr_DecryptAlpha1Server) error {
+	data, err := recvCryptoPayload(stream, &pb.DecryptRequest{})
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(data, []byte(stateEncryptionHeader)) {
+		return errors.New("not an encrypted document")
+	}
+	out := reverseBytes(data[len(stateEncryptionHeader):])
+	return stream.Send(&pb.DecryptResponse{Payload: &commonv1pb.StreamPayload{Data: out}})
+}
+
+func recvCryptoPayload(stream grpc.ServerStream, req pb.CryptoRequests) ([]byte, error) {
+	var data []byte
+	for {
+		req.Reset()
+		err := stream.RecvMsg(req)
+		if errors.Is(err, io.EOF) {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, req.GetPayload().GetData()...)
+	}
+}
+
+func reverseBytes(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[len(data)-1-i] = b
+	}
+	return out
+}
+
+func newEncryptingTestClient(t *testing.T) (*GRPCClient, *testDaprServer) {
+	backend := &testDaprServer{state: make(map[string][]byte)}
+	s := grpc.NewServer()
+	pb.RegisterDaprServer(s, &envelopeCryptoServer{testDaprServer: backend})
+	l := bufconn.Listen(testBufSize)
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return l.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	o := &clientOptions{}
+	WithStateEncryption("mycrypto", "mykey")(o)
+	c := newClientWithConnection(conn, &authToken{})
+	c.stateEncryption = o.stateEncryption
+	return c, backend
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestStateEncryption$
+func TestStateEncryption(t *testing.T) {
+	ctx := context.Background()
+	c, backend := newEncryptingTestClient(t)
+
+	t.Run("round trip", func(t *testing.T) {
+		require.NoError(t, c.SaveState(ctx, testStore, "secret", []byte("plaintext"), nil))
+		stored := backend.state["secret"]
+		assert.True(t, isStateEncrypted(stored))
+		assert.NotContains(t, string(stored), "plaintext")
+
+		item, err := c.GetState(ctx, testStore, "secret", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("plaintext"), item.Value)
+		assert.Equal(t, "mycrypto", item.Metadata[stateMetadataKeyCryptoComponent])
+		assert.Equal(t, "mykey", item.Metadata[stateMetadataKeyCryptoKeyName])
+
+		items, err := c.GetBulkState(ctx, testStore, []string{"secret"}, nil, 1)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, []byte("plaintext"), items[0].Value)
+	})
+
+	t.Run("transaction upserts are encrypted", func(t *testing.T) {
+		err := c.ExecuteStateTransaction(ctx, testStore, nil, []*StateOperation{
+			{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "tx-secret", Value: []byte("plaintext")}},
+		})
+		require.NoError(t, err)
+		assert.True(t, isStateEncrypted(backend.state["tx-secret"]))
+	})
+
+	t.Run("values looking encrypted are encrypted", func(t *testing.T) {
+		lookalike := append([]byte(stateEncryptionHeader), "not encrypted"...)
+		require.NoError(t, c.SaveState(ctx, testStore, "lookalike", lookalike, nil))
+		assert.NotEqual(t, lookalike, backend.state["lookalike"])
+
+		item, err := c.GetState(ctx, testStore, "lookalike", nil)
+		require.NoError(t, err)
+		assert.Equal(t, lookalike, item.Value)
+	})
+
+	t.Run("items read back encrypted are saved as is", func(t *testing.T) {
+		require.NoError(t, c.SaveState(ctx, testStore, "secret", []byte("plaintext"), nil))
+		encrypted := backend.state["secret"]
+
+		// Values returned by QueryStateAlpha1 are left encrypted, re-saving one marked keeps it.
+		meta := map[string]string{StateMetadataKeyEncrypted: "true"}
+		require.NoError(t, c.SaveState(ctx, testStore, "copy", encrypted, meta))
+		assert.Equal(t, encrypted, backend.state["copy"])
+		assert.Contains(t, meta, StateMetadataKeyEncrypted)
+		item, err := c.GetState(ctx, testStore, "copy", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("plaintext"), item.Value)
+
+		// A decrypted item carries the crypto metadata but no marker, it is encrypted again.
+		require.NoError(t, c.SaveState(ctx, testStore, "copy", item.Value, item.Metadata))
+		assert.True(t, isStateEncrypted(backend.state["copy"]))
+		assert.NotContains(t, string(backend.state["copy"]), "plaintext")
+
+		err = c.ExecuteStateTransaction(ctx, testStore, nil, []*StateOperation{
+			{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "tx-copy", Value: encrypted, Metadata: meta}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, encrypted, backend.state["tx-copy"])
+	})
+
+	t.Run("marked plaintext values are encrypted", func(t *testing.T) {
+		meta := map[string]string{StateMetadataKeyEncrypted: "true"}
+		require.NoError(t, c.SaveState(ctx, testStore, "marked", []byte("plaintext"), meta))
+		assert.True(t, isStateEncrypted(backend.state["marked"]))
+	})
+
+	t.Run("plaintext values are rejected", func(t *testing.T) {
+		backend.state["legacy"] = []byte("legacy value")
+		_, err := c.GetState(ctx, testStore, "legacy", nil)
+		require.ErrorIs(t, err, ErrStateNotEncrypted)
+
+		items, err := c.GetBulkState(ctx, testStore, []string{"legacy"}, nil, 1)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Nil(t, items[0].Value)
+		assert.Contains(t, items[0].Error, ErrStateNotEncrypted.Error())
+
+		item, err := c.GetState(ctx, testStore, "missing", nil)
+		require.NoError(t, err)
+		assert.Empty(t, item.Value)
+	})
+
+	t.Run("plaintext values are returned as is when allowed", func(t *testing.T) {
+		lenient := *c
+		encryption := *c.stateEncryption
+		WithStateEncryptionAllowPlaintext()(&encryption)
+		lenient.stateEncryption = &encryption
+
+		backend.state["legacy"] = []byte("legacy value")
+		item, err := lenient.GetState(ctx, testStore, "legacy", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("legacy value"), item.Value)
+		assert.NotContains(t, item.Metadata, stateMetadataKeyCryptoComponent)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewClientWithOptions(ctx, WithPort("50001"), WithStateEncryption("", "mykey"))
+		require.Error(t, err)
+		_, err = NewClientWithOptions(ctx, WithPort("50001"), WithStateEncryption("mycrypto", ""))
+		require.Error(t, err)
+	})
+}
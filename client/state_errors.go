@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StateErrorClass tells how a failed state operation should be handled.
+type StateErrorClass int
+
+const (
+	// StateErrorPermanent errors fail the same way when retried, such as invalid requests.
+	StateErrorPermanent StateErrorClass = iota
+	// StateErrorConflict errors are ETag mismatches, the operation can be retried after reading the key again.
+	StateErrorConflict
+	// StateErrorTransient errors are temporary failures of the sidecar or the store, the operation can be retried with backoff.
+	StateErrorTransient
+	// StateErrorNotFound errors report a missing key or store.
+	StateErrorNotFound
+)
+
+// String returns the name of the class.
+func (c StateErrorClass) String() string {
+	switch c {
+	case StateErrorConflict:
+		return "conflict"
+	case StateErrorTransient:
+		return "transient"
+	case StateErrorNotFound:
+		return "not found"
+	default:
+		return "permanent"
+	}
+}
+
+// StateErrorClassifier classifies the errors of state operations.
+type StateErrorClassifier func(err error) StateErrorClass
+
+// ClassifyStateError classifies err, a non-nil error returned by a state operation, from its gRPC status.
+// Cancelled or expired call contexts are permanent, as retrying them fails immediately.
+func ClassifyStateError(err error) StateErrorClass {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return StateErrorPermanent
+	}
+	switch status.Code(err) {
+	case codes.Aborted:
+		return StateErrorConflict
+	case codes.NotFound:
+		return StateErrorNotFound
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded:
+		return StateErrorTransient
+	}
+	// The runtime reports ETag mismatches of transactions as internal errors.
+	if strings.Contains(strings.ToLower(err.Error()), "etag mismatch") {
+		return StateErrorConflict
+	}
+	return StateErrorPermanent
+}
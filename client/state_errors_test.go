@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// go test -timeout 30s ./client -count 1 -run ^TestClassifyStateError$
+func TestClassifyStateError(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected StateErrorClass
+	}{
+		"aborted":            {status.Error(codes.Aborted, "possible etag mismatch"), StateErrorConflict},
+		"transaction etag":   {status.Error(codes.Internal, "error while executing state transaction: possible etag mismatch"), StateErrorConflict},
+		"wrapped conflict":   {fmt.Errorf("error saving state: %w", status.Error(codes.Aborted, "conflict")), StateErrorConflict},
+		"not found":          {status.Error(codes.NotFound, "state store not found"), StateErrorNotFound},
+		"unavailable":        {status.Error(codes.Unavailable, "connection refused"), StateErrorTransient},
+		"resource exhausted": {status.Error(codes.ResourceExhausted, "throttled"), StateErrorTransient},
+		"server deadline":    {status.Error(codes.DeadlineExceeded, "store timeout"), StateErrorTransient},
+		"invalid argument":   {status.Error(codes.InvalidArgument, "invalid etag"), StateErrorPermanent},
+		"internal":           {status.Error(codes.Internal, "failed saving state"), StateErrorPermanent},
+		"permission denied":  {status.Error(codes.PermissionDenied, "denied"), StateErrorPermanent},
+		"context cancelled":  {fmt.Errorf("error getting state: %w", context.Canceled), StateErrorPermanent},
+		"context deadline":   {context.DeadlineExceeded, StateErrorPermanent},
+		"plain error":        {errors.New("boom"), StateErrorPermanent},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClassifyStateError(tt.err))
+		})
+	}
+
+	assert.Equal(t, "conflict", StateErrorConflict.String())
+	assert.Equal(t, "permanent", StateErrorPermanent.String())
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// compositeKeySeparator separates the parts of an encoded CompositeKey.
+const compositeKeySeparator = ':'
+
+// CompositeKey is a state key made of several parts, such as a tenant and an entity ID.
+// It is taken by SaveStateKey, GetStateKey and DeleteStateKey, and its String encoding is stable
+// and can be used wherever a state key is taken, for example GetBulkState(ctx, store, CompositeKeys(keys...), nil, 0).
+type CompositeKey struct {
+	parts []string
+}
+
+// NewCompositeKey returns the composite key made of parts, in order. A key without parts, or made
+// of a single empty part, would encode to an empty state key and is rejected.
+func NewCompositeKey(parts ...string) (CompositeKey, error) {
+	if len(parts) == 0 || (len(parts) == 1 && parts[0] == "") {
+		return CompositeKey{}, errors.New("composite key requires at least one non-empty part")
+	}
+	return CompositeKey{parts: append([]string(nil), parts...)}, nil
+}
+
+// Parts returns the parts of the key.
+func (k CompositeKey) Parts() []string {
+	return append([]string(nil), k.parts...)
+}
+
+// String encodes the key by joining its parts with ':'. Colons, percent signs and pipes within
+// parts are percent-encoded, so the encoding round-trips with ParseCompositeKey and never contains
+// the "||" separator reserved by the Dapr runtime.
+func (k CompositeKey) String() string {
+	var b strings.Builder
+	for i, part := range k.parts {
+		if i > 0 {
+			b.WriteByte(compositeKeySeparator)
+		}
+		for j := 0; j < len(part); j++ {
+			switch c := part[j]; c {
+			case compositeKeySeparator, '%', '|':
+				fmt.Fprintf(&b, "%%%02X", c)
+			default:
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
+
+// ParseCompositeKey decodes a key encoded with CompositeKey.String.
+func ParseCompositeKey(s string) (CompositeKey, error) {
+	if s == "" {
+		return CompositeKey{}, errors.New("empty composite key")
+	}
+	var (
+		parts []string
+		part  strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case compositeKeySeparator:
+			parts = append(parts, part.String())
+			part.Reset()
+		case '%':
+			if i+2 >= len(s) {
+				return CompositeKey{}, fmt.Errorf("invalid composite key %q: truncated escape at offset %d", s, i)
+			}
+			v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				return CompositeKey{}, fmt.Errorf("invalid composite key %q: invalid escape at offset %d", s, i)
+			}
+			part.WriteByte(byte(v))
+			i += 2
+		case '|':
+			return CompositeKey{}, fmt.Errorf("invalid composite key %q: unescaped '|' at offset %d", s, i)
+		default:
+			part.WriteByte(c)
+		}
+	}
+	parts = append(parts, part.String())
+	return CompositeKey{parts: parts}, nil
+}
+
+// CompositeKeys encodes keys for the calls taking several state keys, such as GetBulkState and DeleteBulkState.
+func CompositeKeys(keys ...CompositeKey) []string {
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = k.String()
+	}
+	return out
+}
+
+// stateKey returns the encoding of k for the typed state calls, rejecting the zero key.
+func (k CompositeKey) stateKey() (string, error) {
+	if len(k.parts) == 0 {
+		return "", errors.New("composite key required")
+	}
+	return k.String(), nil
+}
+
+// SaveStateKey saves data under the encoding of key, see SaveState.
+func (c *GRPCClient) SaveStateKey(ctx context.Context, storeName string, key CompositeKey, data []byte, meta map[string]string, so ...StateOption) error {
+	k, err := key.stateKey()
+	if err != nil {
+		return err
+	}
+	return c.SaveState(ctx, storeName, k, data, meta, so...)
+}
+
+// GetStateKey retrieves the state saved under the encoding of key, see GetState.
+// The returned item carries the encoded key, which ParseCompositeKey decodes.
+func (c *GRPCClient) GetStateKey(ctx context.Context, storeName string, key CompositeKey, meta map[string]string) (*StateItem, error) {
+	k, err := key.stateKey()
+	if err != nil {
+		return nil, err
+	}
+	return c.GetState(ctx, storeName, k, meta)
+}
+
+// DeleteStateKey deletes the state saved under the encoding of key, see DeleteState.
+func (c *GRPCClient) DeleteStateKey(ctx context.Context, storeName string, key CompositeKey, meta map[string]string) error {
+	k, err := key.stateKey()
+	if err != nil {
+		return err
+	}
+	return c.DeleteState(ctx, storeName, k, meta)
+}
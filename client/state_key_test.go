@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test -timeout 30s ./client -count 1 -run ^TestCompositeKey$
+func TestCompositeKey(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		tests := map[string][]string{
+			"simple":       {"tenant1", "order", "42"},
+			"single part":  {"key"},
+			"separator":    {"a:b", "c"},
+			"percent":      {"50%", "%3A"},
+			"reserved":     {"x||y", "|"},
+			"empty part":   {"tenant1", "", "42"},
+			"unicode":      {"tenañt", "注文"},
+			"trailing sep": {"a", ""},
+		}
+		validating := &GRPCClient{stateKeyValidation: true}
+		for name, parts := range tests {
+			t.Run(name, func(t *testing.T) {
+				key, err := NewCompositeKey(parts...)
+				require.NoError(t, err)
+				encoded := key.String()
+				assert.NotContains(t, encoded, "||")
+				require.NoError(t, validating.checkStateKeys(encoded))
+
+				parsed, err := ParseCompositeKey(encoded)
+				require.NoError(t, err)
+				assert.Equal(t, parts, parsed.Parts())
+				assert.Equal(t, encoded, parsed.String())
+			})
+		}
+	})
+
+	t.Run("stable encoding", func(t *testing.T) {
+		key, err := NewCompositeKey("tenant1", "order", "42")
+		require.NoError(t, err)
+		assert.Equal(t, "tenant1:order:42", key.String())
+		key, err = NewCompositeKey("a:b", "100%", "x||y")
+		require.NoError(t, err)
+		assert.Equal(t, "a%3Ab:100%25:x%7C%7Cy", key.String())
+	})
+
+	t.Run("empty keys are rejected", func(t *testing.T) {
+		_, err := NewCompositeKey()
+		require.Error(t, err)
+		_, err = NewCompositeKey("")
+		require.Error(t, err)
+
+		key, err := NewCompositeKey("", "")
+		require.NoError(t, err)
+		parsed, err := ParseCompositeKey(key.String())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", ""}, parsed.Parts())
+	})
+
+	t.Run("invalid encodings", func(t *testing.T) {
+		for _, s := range []string{"", "a%", "a%3", "a%zz", "a||b"} {
+			_, err := ParseCompositeKey(s)
+			require.Error(t, err, s)
+		}
+	})
+
+	t.Run("keys with state calls", func(t *testing.T) {
+		ctx := context.Background()
+		keys := make([]CompositeKey, 0, 2)
+		for _, id := range []string{"a", "b"} {
+			key, err := NewCompositeKey("tenant1", id)
+			require.NoError(t, err)
+			keys = append(keys, key)
+		}
+		for _, k := range keys {
+			require.NoError(t, testClient.SaveStateKey(ctx, testStore, k, []byte(testData), nil))
+		}
+		t.Cleanup(func() {
+			require.NoError(t, testClient.DeleteBulkState(ctx, testStore, CompositeKeys(keys...), nil))
+		})
+
+		items, err := testClient.GetBulkState(ctx, testStore, CompositeKeys(keys...), nil, 1)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		parsed, err := ParseCompositeKey(items[0].Key)
+		require.NoError(t, err)
+		assert.Equal(t, "tenant1", parsed.Parts()[0])
+	})
+
+	t.Run("typed state calls", func(t *testing.T) {
+		ctx := context.Background()
+		key, err := NewCompositeKey("tenant1", "a:b")
+		require.NoError(t, err)
+		require.NoError(t, testClient.SaveStateKey(ctx, testStore, key, []byte(testData), nil))
+
+		item, err := testClient.GetStateKey(ctx, testStore, key, nil)
+		require.NoError(t, err)
+		assert.Equal(t, testData, string(item.Value))
+		assert.Equal(t, key.String(), item.Key)
+
+		require.NoError(t, testClient.DeleteStateKey(ctx, testStore, key, nil))
+		item, err = testClient.GetState(ctx, testStore, key.String(), nil)
+		require.NoError(t, err)
+		assert.Empty(t, item.Value)
+
+		require.Error(t, testClient.SaveStateKey(ctx, testStore, CompositeKey{}, []byte(testData), nil))
+		_, err = testClient.GetStateKey(ctx, testStore, CompositeKey{}, nil)
+		require.Error(t, err)
+		require.Error(t, testClient.DeleteStateKey(ctx, testStore, CompositeKey{}, nil))
+	})
+}
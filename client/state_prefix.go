@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	deleteByPrefixBatchSizeDefault = 100
+	listStateKeysPageSizeDefault   = 100
+
+	errorReasonStateQueryUnsupported = "DAPR_STATE_QUERYING_NOT_SUPPORTED"
+)
+
+// DeleteByPrefixOption configures DeleteStateByPrefix.
+type DeleteByPrefixOption func(*deleteByPrefixOptions)
+
+type deleteByPrefixOptions struct {
+	batchSize int
+	meta      map[string]string
+}
+
+// WithDeleteBatchSize sets how many keys are listed and deleted per request, defaults to 100.
+func WithDeleteBatchSize(n int) DeleteByPrefixOption {
+	return func(o *deleteByPrefixOptions) {
+		o.batchSize = n
+	}
+}
+
+// WithDeleteMetadata sets the metadata sent with the query and delete requests.
+func WithDeleteMetadata(meta map[string]string) DeleteByPrefixOption {
+	return func(o *deleteByPrefixOptions) {
+		o.meta = meta
+	}
+}
+
+// DeleteStateByPrefix deletes all keys of the store starting with prefix and returns the number of deleted keys.
+// The keys are enumerated with the query API, stores which don't support querying return an error
+// wrapping errors.ErrUnsupported. The deletion isn't atomic: keys are deleted in batches after being
+// listed, so keys written concurrently may be missed and a failure can leave some keys deleted.
+func (c *GRPCClient) DeleteStateByPrefix(ctx context.Context, storeName, prefix string, opts ...DeleteByPrefixOption) (int, error) {
+	if prefix == "" {
+		return 0, errors.New("prefix required: use DeleteBulkState to delete specific keys")
+	}
+	o := &deleteByPrefixOptions{batchSize: deleteByPrefixBatchSizeDefault}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.batchSize < 1 {
+		return 0, fmt.Errorf("invalid batch size %d: must be at least 1", o.batchSize)
+	}
+
+	keys, err := c.listStateKeys(ctx, storeName, prefix, o.batchSize, o.meta)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for start := 0; start < len(keys); start += o.batchSize {
+		batch := keys[start:min(start+o.batchSize, len(keys))]
+		if err := c.DeleteBulkState(ctx, storeName, batch, o.meta); err != nil {
+			return deleted, fmt.Errorf("error deleting keys with prefix %s after %d deletions: %w", prefix, deleted, err)
+		}
+		deleted += len(batch)
+	}
+	return deleted, nil
+}
+
+// ListStateKeysOption configures ListStateKeys.
+type ListStateKeysOption func(*listStateKeysOptions)
+
+type listStateKeysOptions struct {
+	pageSize int
+	meta     map[string]string
+}
+
+// WithListPageSize sets how many items are requested per query page, defaults to 100.
+func WithListPageSize(n int) ListStateKeysOption {
+	return func(o *listStateKeysOptions) {
+		o.pageSize = n
+	}
+}
+
+// WithListMetadata sets the metadata sent with the query requests.
+func WithListMetadata(meta map[string]string) ListStateKeysOption {
+	return func(o *listStateKeysOptions) {
+		o.meta = meta
+	}
+}
+
+// ListStateKeys returns all keys of the store starting with prefix, an empty prefix lists all keys.
+// The keys are enumerated with the query API, paging internally until the last page, so it is meant
+// for debugging and small stores. Stores which don't support querying return an error wrapping
+// errors.ErrUnsupported.
+func (c *GRPCClient) ListStateKeys(ctx context.Context, storeName, prefix string, opts ...ListStateKeysOption) ([]string, error) {
+	o := &listStateKeysOptions{pageSize: listStateKeysPageSizeDefault}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.pageSize < 1 {
+		return nil, fmt.Errorf("invalid page size %d: must be at least 1", o.pageSize)
+	}
+	return c.listStateKeys(ctx, storeName, prefix, o.pageSize, o.meta)
+}
+
+// listStateKeys pages through all items of the store with the query API, returning the keys starting with prefix.
+func (c *GRPCClient) listStateKeys(ctx context.Context, storeName, prefix string, pageSize int, meta map[string]string) ([]string, error) {
+	keys := make([]string, 0)
+	token := ""
+	for {
+		query, err := NewQueryBuilder().Page(pageSize, token).Build()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.QueryStateAlpha1(ctx, storeName, query, meta)
+		if err != nil {
+			if isStateQueryUnsupported(err) {
+				return nil, fmt.Errorf("state store %s can't list keys: %w: %w", storeName, errors.ErrUnsupported, err)
+			}
+			return nil, err
+		}
+		for _, item := range resp.Results {
+			if strings.HasPrefix(item.Key, prefix) {
+				keys = append(keys, item.Key)
+			}
+		}
+		if resp.Token == "" || resp.Token == token || len(resp.Results) == 0 {
+			return keys, nil
+		}
+		token = resp.Token
+	}
+}
+
+// isStateQueryUnsupported reports whether err indicates the state store doesn't support the query API.
+func isStateQueryUnsupported(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	if st.Code() == codes.Unimplemented {
+		return true
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetReason() == errorReasonStateQueryUnsupported {
+			return true
+		}
+	}
+	return strings.Contains(st.Message(), "does not support querying")
+}
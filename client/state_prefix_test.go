@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// pagedQueryClient serves the keys over pages of two items and records deletions.
+type pagedQueryClient struct {
+	pb.DaprClient
+	keys     []string
+	queryErr error
+	deleted  [][]string
+}
+
+func (c *pagedQueryClient) QueryStateAlpha1(ctx context.Context, in *pb.QueryStateRequest, opts ...grpc.CallOption) (*pb.QueryStateResponse, error) {
+	if c.queryErr != nil {
+		return nil, c.queryErr
+	}
+	var q struct {
+		Page struct {
+			Token string `json:"token"`
+		} `json:"page"`
+	}
+	if err := json.Unmarshal([]byte(in.GetQuery()), &q); err != nil {
+		return nil, err
+	}
+	start := 0
+	if q.Page.Token != "" {
+		start = int(q.Page.Token[0] - '0')
+	}
+	end := min(start+2, len(c.keys))
+	resp := &pb.QueryStateResponse{}
+	for _, k := range c.keys[start:end] {
+		resp.Results = append(resp.Results, &pb.QueryStateItem{Key: k})
+	}
+	if end < len(c.keys) {
+		resp.Token = string(rune('0' + end))
+	}
+	return resp, nil
+}
+
+func (c *pagedQueryClient) DeleteBulkState(ctx context.Context, in *pb.DeleteBulkStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	batch := make([]string, 0, len(in.GetStates()))
+	for _, s := range in.GetStates() {
+		batch = append(batch, s.GetKey())
+	}
+	c.deleted = append(c.deleted, batch)
+	return &emptypb.Empty{}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestDeleteStateByPrefix$
+func TestDeleteStateByPrefix(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("deletes prefixed keys across pages in batches", func(t *testing.T) {
+		proto := &pagedQueryClient{keys: []string{"order-1", "user-1", "order-2", "order-3", "user-2"}}
+		c := &GRPCClient{protoClient: proto}
+		n, err := c.DeleteStateByPrefix(ctx, testStore, "order-", WithDeleteBatchSize(2))
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, [][]string{{"order-1", "order-2"}, {"order-3"}}, proto.deleted)
+	})
+
+	t.Run("no matching keys", func(t *testing.T) {
+		proto := &pagedQueryClient{keys: []string{"user-1"}}
+		c := &GRPCClient{protoClient: proto}
+		n, err := c.DeleteStateByPrefix(ctx, testStore, "order-")
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+		assert.Empty(t, proto.deleted)
+	})
+
+	t.Run("store without query support", func(t *testing.T) {
+		st, err := status.New(codes.Internal, "state store does not support querying").
+			WithDetails(&errdetails.ErrorInfo{Reason: "DAPR_STATE_QUERYING_NOT_SUPPORTED"})
+		require.NoError(t, err)
+		c := &GRPCClient{protoClient: &pagedQueryClient{queryErr: st.Err()}}
+		_, err = c.DeleteStateByPrefix(ctx, testStore, "order-")
+		require.ErrorIs(t, err, errors.ErrUnsupported)
+	})
+
+	t.Run("other query errors are returned", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &pagedQueryClient{queryErr: status.Error(codes.Unavailable, "down")}}
+		_, err := c.DeleteStateByPrefix(ctx, testStore, "order-")
+		require.Error(t, err)
+		require.NotErrorIs(t, err, errors.ErrUnsupported)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := testClient.DeleteStateByPrefix(ctx, testStore, "")
+		require.Error(t, err)
+		_, err = testClient.DeleteStateByPrefix(ctx, testStore, "order-", WithDeleteBatchSize(0))
+		require.Error(t, err)
+	})
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestListStateKeys$
+func TestListStateKeys(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("lists prefixed keys across pages", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &pagedQueryClient{keys: []string{"order-1", "user-1", "order-2", "order-3", "user-2"}}}
+		keys, err := c.ListStateKeys(ctx, testStore, "order-")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"order-1", "order-2", "order-3"}, keys)
+	})
+
+	t.Run("empty prefix lists all keys", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &pagedQueryClient{keys: []string{"order-1", "user-1", "user-2"}}}
+		keys, err := c.ListStateKeys(ctx, testStore, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"order-1", "user-1", "user-2"}, keys)
+	})
+
+	t.Run("store without query support", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &pagedQueryClient{queryErr: status.Error(codes.Unimplemented, "not implemented")}}
+		_, err := c.ListStateKeys(ctx, testStore, "order-")
+		require.ErrorIs(t, err, errors.ErrUnsupported)
+	})
+
+	t.Run("invalid page size", func(t *testing.T) {
+		_, err := testClient.ListStateKeys(ctx, testStore, "order-", WithListPageSize(0))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// QueryOperator is a comparison operator of the state query language.
+type QueryOperator string
+
+const (
+	// QueryOpEQ matches values equal to the given value.
+	QueryOpEQ QueryOperator = "EQ"
+	// QueryOpNEQ matches values not equal to the given value.
+	QueryOpNEQ QueryOperator = "NEQ"
+	// QueryOpGT matches values greater than the given value.
+	QueryOpGT QueryOperator = "GT"
+	// QueryOpGTE matches values greater than or equal to the given value.
+	QueryOpGTE QueryOperator = "GTE"
+	// QueryOpLT matches values less than the given value.
+	QueryOpLT QueryOperator = "LT"
+	// QueryOpLTE matches values less than or equal to the given value.
+	QueryOpLTE QueryOperator = "LTE"
+	// QueryOpIN matches values contained in the given slice.
+	QueryOpIN QueryOperator = "IN"
+
+	queryOpAND = "AND"
+	queryOpOR  = "OR"
+)
+
+func (o QueryOperator) valid() bool {
+	switch o {
+	case QueryOpEQ, QueryOpNEQ, QueryOpGT, QueryOpGTE, QueryOpLT, QueryOpLTE, QueryOpIN:
+		return true
+	default:
+		return false
+	}
+}
+
+// QueryFilter is a single condition or a group of conditions of a state query.
+type QueryFilter struct {
+	op       string
+	field    string
+	value    any
+	children []QueryFilter
+}
+
+// QueryWhere returns a filter comparing field to value using op.
+func QueryWhere(field string, op QueryOperator, value any) QueryFilter {
+	return QueryFilter{op: string(op), field: field, value: value}
+}
+
+// QueryAnd returns a filter matching when all the given filters match.
+func QueryAnd(filters ...QueryFilter) QueryFilter {
+	return QueryFilter{op: queryOpAND, children: filters}
+}
+
+// QueryOr returns a filter matching when any of the given filters match.
+func QueryOr(filters ...QueryFilter) QueryFilter {
+	return QueryFilter{op: queryOpOR, children: filters}
+}
+
+func (f QueryFilter) build() (any, error) {
+	if f.op == queryOpAND || f.op == queryOpOR {
+		if len(f.children) < 2 {
+			return nil, fmt.Errorf("%s filter requires at least 2 conditions", f.op)
+		}
+		children := make([]any, 0, len(f.children))
+		for _, child := range f.children {
+			c, err := child.build()
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, c)
+		}
+		return map[string]any{f.op: children}, nil
+	}
+
+	op := QueryOperator(f.op)
+	if !op.valid() {
+		return nil, fmt.Errorf("invalid query operator %q", f.op)
+	}
+	if f.field == "" {
+		return nil, fmt.Errorf("field name required for %s filter", f.op)
+	}
+	if op == QueryOpIN {
+		if kind := reflect.ValueOf(f.value).Kind(); kind != reflect.Slice && kind != reflect.Array {
+			return nil, fmt.Errorf("%s filter on %s requires a slice value", f.op, f.field)
+		}
+	}
+	return map[string]any{f.op: map[string]any{f.field: f.value}}, nil
+}
+
+type querySort struct {
+	Key   string `json:"key"`
+	Order string `json:"order,omitempty"`
+}
+
+type queryPage struct {
+	Limit int    `json:"limit"`
+	Token string `json:"token,omitempty"`
+}
+
+type query struct {
+	Filter any         `json:"filter,omitempty"`
+	Sort   []querySort `json:"sort,omitempty"`
+	Page   *queryPage  `json:"page,omitempty"`
+}
+
+// QueryBuilder builds the JSON query passed to QueryStateAlpha1.
+// Conditions added with Where, And and Or are combined with AND.
+type QueryBuilder struct {
+	filters []QueryFilter
+	sort    []querySort
+	page    *queryPage
+}
+
+// NewQueryBuilder returns an empty state query builder.
+func NewQueryBuilder() *QueryBuilder {
+	return &QueryBuilder{}
+}
+
+// Where adds a condition comparing field to value using op.
+func (b *QueryBuilder) Where(field string, op QueryOperator, value any) *QueryBuilder {
+	b.filters = append(b.filters, QueryWhere(field, op, value))
+	return b
+}
+
+// And adds a group of conditions which must all match.
+func (b *QueryBuilder) And(filters ...QueryFilter) *QueryBuilder {
+	b.filters = append(b.filters, QueryAnd(filters...))
+	return b
+}
+
+// Or adds a group of conditions of which at least one must match.
+func (b *QueryBuilder) Or(filters ...QueryFilter) *QueryBuilder {
+	b.filters = append(b.filters, QueryOr(filters...))
+	return b
+}
+
+// Sort adds a sort key, sort keys are applied in the order they are added.
+func (b *QueryBuilder) Sort(field string, asc bool) *QueryBuilder {
+	order := "DESC"
+	if asc {
+		order = "ASC"
+	}
+	b.sort = append(b.sort, querySort{Key: field, Order: order})
+	return b
+}
+
+// Page sets the maximum number of results and the continuation token returned by a previous query.
+func (b *QueryBuilder) Page(limit int, token string) *QueryBuilder {
+	b.page = &queryPage{Limit: limit, Token: token}
+	return b
+}
+
+// Build validates the query and returns its JSON representation.
+func (b *QueryBuilder) Build() (string, error) {
+	q := query{Sort: b.sort, Page: b.page}
+
+	switch len(b.filters) {
+	case 0:
+	case 1:
+		f, err := b.filters[0].build()
+		if err != nil {
+			return "", err
+		}
+		q.Filter = f
+	default:
+		f, err := QueryAnd(b.filters...).build()
+		if err != nil {
+			return "", err
+		}
+		q.Filter = f
+	}
+
+	for _, s := range b.sort {
+		if s.Key == "" {
+			return "", errors.New("sort field name required")
+		}
+	}
+	if b.page != nil && b.page.Limit < 0 {
+		return "", errors.New("page limit must not be negative")
+	}
+
+	data, err := json.Marshal(q)
+	if err != nil {
+		return "", fmt.Errorf("error serializing query: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	tests := map[string]struct {
+		builder  *QueryBuilder
+		expected string
+	}{
+		"empty query": {
+			builder:  NewQueryBuilder(),
+			expected: `{}`,
+		},
+		"single condition": {
+			builder:  NewQueryBuilder().Where("state", QueryOpEQ, "CA"),
+			expected: `{"filter":{"EQ":{"state":"CA"}}}`,
+		},
+		"multiple conditions are combined with AND": {
+			builder: NewQueryBuilder().
+				Where("state", QueryOpEQ, "CA").
+				Where("person.age", QueryOpGTE, 21),
+			expected: `{"filter":{"AND":[{"EQ":{"state":"CA"}},{"GTE":{"person.age":21}}]}}`,
+		},
+		"or group with nested and": {
+			builder: NewQueryBuilder().Or(
+				QueryWhere("person.org", QueryOpEQ, "Dev Ops"),
+				QueryAnd(
+					QueryWhere("person.org", QueryOpEQ, "Finance"),
+					QueryWhere("state", QueryOpIN, []string{"CA", "WA"}),
+				),
+			),
+			expected: `{"filter":{"OR":[{"EQ":{"person.org":"Dev Ops"}},{"AND":[{"EQ":{"person.org":"Finance"}},{"IN":{"state":["CA","WA"]}}]}]}}`,
+		},
+		"sort and page": {
+			builder: NewQueryBuilder().
+				Where("state", QueryOpNEQ, "CA").
+				Sort("state", false).
+				Sort("person.id", true).
+				Page(3, "next"),
+			expected: `{"filter":{"NEQ":{"state":"CA"}},"sort":[{"key":"state","order":"DESC"},{"key":"person.id","order":"ASC"}],"page":{"limit":3,"token":"next"}}`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.builder.Build()
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, got)
+		})
+	}
+}
+
+func TestQueryBuilderErrors(t *testing.T) {
+	tests := map[string]*QueryBuilder{
+		"empty field":          NewQueryBuilder().Where("", QueryOpEQ, "CA"),
+		"invalid operator":     NewQueryBuilder().Where("state", QueryOperator("LIKE"), "CA"),
+		"in without slice":     NewQueryBuilder().Where("state", QueryOpIN, "CA"),
+		"single item or group": NewQueryBuilder().Or(QueryWhere("state", QueryOpEQ, "CA")),
+		"invalid nested":       NewQueryBuilder().And(QueryWhere("state", QueryOpEQ, "CA"), QueryWhere("", QueryOpEQ, "WA")),
+		"empty sort field":     NewQueryBuilder().Sort("", true),
+		"negative page limit":  NewQueryBuilder().Page(-1, ""),
+	}
+
+	for name, b := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := b.Build()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestQueryStateWithBuilder(t *testing.T) {
+	query, err := NewQueryBuilder().Where("state", QueryOpEQ, "CA").Build()
+	require.NoError(t, err)
+	_, err = testClient.QueryStateAlpha1(context.Background(), testStore, query, nil)
+	require.NoError(t, err)
+}
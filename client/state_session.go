@@ -0,0 +1,321 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	sessionMaxWaitDefault       = time.Second
+	sessionRetryIntervalDefault = 20 * time.Millisecond
+)
+
+// ErrSessionStaleRead is returned by SessionClient.GetState when the written value wasn't observed in time.
+var ErrSessionStaleRead = errors.New("state read did not observe the session's latest write")
+
+// SessionOption configures a SessionClient.
+type SessionOption func(*SessionClient)
+
+// WithSessionMaxWait bounds how long GetState retries to observe a previous write, defaults to 1 second.
+func WithSessionMaxWait(d time.Duration) SessionOption {
+	return func(s *SessionClient) {
+		s.maxWait = d
+	}
+}
+
+// WithSessionRetryInterval sets the delay between GetState retries, defaults to 20 milliseconds.
+func WithSessionRetryInterval(d time.Duration) SessionOption {
+	return func(s *SessionClient) {
+		s.retryInterval = d
+	}
+}
+
+type sessionWrite struct {
+	sum     [sha256.Size]byte
+	deleted bool
+}
+
+// SessionClient wraps a Client to provide read-your-writes consistency for keys saved or deleted
+// through it, which is useful with eventually consistent state stores.
+// SaveState doesn't return the resulting ETag, so the session records a hash of each written value
+// and the reads of the same key retry until they read that value or the maximum wait elapses.
+// The single, bulk and transactional writes are recorded, and the GetState, GetStateWithConsistency
+// and GetBulkState reads are retried. The hash of a write is kept for the lifetime of the session,
+// until a newer write of the key replaces it, as a later read may hit a replica which lags behind.
+// When a write fails its keys are forgotten, since their state is then unknown. All other methods are passed through to the wrapped client.
+type SessionClient struct {
+	Client
+
+	maxWait       time.Duration
+	retryInterval time.Duration
+
+	mu     sync.Mutex
+	writes map[string]sessionWrite
+}
+
+// NewSessionClient returns a SessionClient wrapping c.
+func NewSessionClient(c Client, opts ...SessionOption) *SessionClient {
+	s := &SessionClient{
+		Client:        c,
+		maxWait:       sessionMaxWaitDefault,
+		retryInterval: sessionRetryIntervalDefault,
+		writes:        make(map[string]sessionWrite),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func sessionKey(storeName, key string) string {
+	return CompositeKey{parts: []string{storeName, key}}.String()
+}
+
+func (s *SessionClient) record(storeName, key string, w sessionWrite) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes[sessionKey(storeName, key)] = w
+}
+
+func (s *SessionClient) forget(storeName string, keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.writes, sessionKey(storeName, key))
+	}
+}
+
+// recordItems records the values of items when err is nil, and forgets their keys otherwise.
+func (s *SessionClient) recordItems(storeName string, items []*SetStateItem, err error) {
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if err != nil {
+			s.forget(storeName, item.Key)
+			continue
+		}
+		s.record(storeName, item.Key, sessionWrite{sum: sha256.Sum256(item.Value)})
+	}
+}
+
+// recordDeletes records the deletion of keys when err is nil, and forgets them otherwise.
+func (s *SessionClient) recordDeletes(storeName string, keys []string, err error) {
+	if err != nil {
+		s.forget(storeName, keys...)
+		return
+	}
+	for _, key := range keys {
+		s.record(storeName, key, sessionWrite{deleted: true})
+	}
+}
+
+// SaveState saves the value and records it for subsequent reads.
+func (s *SessionClient) SaveState(ctx context.Context, storeName, key string, data []byte, meta map[string]string, so ...StateOption) error {
+	if err := s.Client.SaveState(ctx, storeName, key, data, meta, so...); err != nil {
+		return err
+	}
+	s.record(storeName, key, sessionWrite{sum: sha256.Sum256(data)})
+	return nil
+}
+
+// SaveStateWithETag saves the value with an ETag and records it for subsequent reads.
+func (s *SessionClient) SaveStateWithETag(ctx context.Context, storeName, key string, data []byte, etag string, meta map[string]string, so ...StateOption) error {
+	if err := s.Client.SaveStateWithETag(ctx, storeName, key, data, etag, meta, so...); err != nil {
+		return err
+	}
+	s.record(storeName, key, sessionWrite{sum: sha256.Sum256(data)})
+	return nil
+}
+
+// SaveStateChanged saves the value, reports whether a write occurred and records it for subsequent reads.
+func (s *SessionClient) SaveStateChanged(ctx context.Context, storeName, key string, data []byte, meta map[string]string, so ...StateOption) (bool, error) {
+	changed, err := s.Client.SaveStateChanged(ctx, storeName, key, data, meta, so...)
+	if err != nil {
+		return false, err
+	}
+	s.record(storeName, key, sessionWrite{sum: sha256.Sum256(data)})
+	return changed, nil
+}
+
+// SaveBulkState saves the items and records them for subsequent reads.
+func (s *SessionClient) SaveBulkState(ctx context.Context, storeName string, items ...*SetStateItem) error {
+	err := s.Client.SaveBulkState(ctx, storeName, items...)
+	s.recordItems(storeName, items, err)
+	return err
+}
+
+// SaveBulkStateChunked saves the items in chunks and records them for subsequent reads.
+func (s *SessionClient) SaveBulkStateChunked(ctx context.Context, storeName string, items []*SetStateItem, maxBytes int, opts ...StateOption) error {
+	err := s.Client.SaveBulkStateChunked(ctx, storeName, items, maxBytes, opts...)
+	s.recordItems(storeName, items, err)
+	return err
+}
+
+// DeleteState deletes the key and records the deletion for subsequent reads.
+func (s *SessionClient) DeleteState(ctx context.Context, storeName, key string, meta map[string]string) error {
+	err := s.Client.DeleteState(ctx, storeName, key, meta)
+	s.recordDeletes(storeName, []string{key}, err)
+	return err
+}
+
+// DeleteStateWithETag deletes the key with an ETag and records the deletion for subsequent reads.
+func (s *SessionClient) DeleteStateWithETag(ctx context.Context, storeName, key string, etag *ETag, meta map[string]string, opts *StateOptions) error {
+	err := s.Client.DeleteStateWithETag(ctx, storeName, key, etag, meta, opts)
+	s.recordDeletes(storeName, []string{key}, err)
+	return err
+}
+
+// DeleteBulkState deletes the keys and records the deletions for subsequent reads.
+func (s *SessionClient) DeleteBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string) error {
+	err := s.Client.DeleteBulkState(ctx, storeName, keys, meta)
+	s.recordDeletes(storeName, keys, err)
+	return err
+}
+
+// DeleteBulkStateItems deletes the items and records the deletions for subsequent reads.
+func (s *SessionClient) DeleteBulkStateItems(ctx context.Context, storeName string, items []*DeleteStateItem) error {
+	err := s.Client.DeleteBulkStateItems(ctx, storeName, items)
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != nil {
+			keys = append(keys, item.Key)
+		}
+	}
+	s.recordDeletes(storeName, keys, err)
+	return err
+}
+
+// ExecuteStateTransaction executes the operations and records their upserts and deletions for subsequent reads.
+func (s *SessionClient) ExecuteStateTransaction(ctx context.Context, storeName string, meta map[string]string, ops []*StateOperation) error {
+	err := s.Client.ExecuteStateTransaction(ctx, storeName, meta, ops)
+	for _, op := range ops {
+		if op == nil || op.Item == nil {
+			continue
+		}
+		if op.Type == StateOperationTypeDelete {
+			s.recordDeletes(storeName, []string{op.Item.Key}, err)
+			continue
+		}
+		s.recordItems(storeName, []*SetStateItem{op.Item}, err)
+	}
+	return err
+}
+
+// GetState retrieves the state, retrying until the session's latest write to the key is observed.
+// When the write isn't observed within the maximum wait, the last item read is returned together
+// with ErrSessionStaleRead.
+func (s *SessionClient) GetState(ctx context.Context, storeName, key string, meta map[string]string) (*StateItem, error) {
+	return s.getState(ctx, storeName, key, func() (*StateItem, error) {
+		return s.Client.GetState(ctx, storeName, key, meta)
+	})
+}
+
+// GetStateWithConsistency retrieves the state with the given consistency, retrying like GetState.
+func (s *SessionClient) GetStateWithConsistency(ctx context.Context, storeName, key string, meta map[string]string, sc StateConsistency) (*StateItem, error) {
+	return s.getState(ctx, storeName, key, func() (*StateItem, error) {
+		return s.Client.GetStateWithConsistency(ctx, storeName, key, meta, sc)
+	})
+}
+
+// GetBulkState retrieves the state of keys, retrying until the session's latest writes to all of them
+// are observed. When they aren't observed within the maximum wait, the last items read are returned
+// together with ErrSessionStaleRead.
+func (s *SessionClient) GetBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string, parallelism int32) ([]*BulkStateItem, error) {
+	tracked := make(map[string]sessionWrite)
+	s.mu.Lock()
+	for _, key := range keys {
+		if w, ok := s.writes[sessionKey(storeName, key)]; ok {
+			tracked[key] = w
+		}
+	}
+	s.mu.Unlock()
+
+	items, err := s.Client.GetBulkState(ctx, storeName, keys, meta, parallelism)
+	if err != nil || len(tracked) == 0 {
+		return items, err
+	}
+
+	observed := func() bool {
+		for _, item := range items {
+			w, ok := tracked[item.Key]
+			if ok && !w.observedIn(&StateItem{Key: item.Key, Value: item.Value}) {
+				return false
+			}
+		}
+		return true
+	}
+	deadline := time.Now().Add(s.maxWait)
+	for !observed() {
+		if time.Now().After(deadline) {
+			return items, ErrSessionStaleRead
+		}
+		select {
+		case <-ctx.Done():
+			return items, ctx.Err()
+		case <-time.After(s.retryInterval):
+		}
+		if items, err = s.Client.GetBulkState(ctx, storeName, keys, meta, parallelism); err != nil {
+			return items, err
+		}
+	}
+
+	return items, nil
+}
+
+func (s *SessionClient) getState(ctx context.Context, storeName, key string, read func() (*StateItem, error)) (*StateItem, error) {
+	sk := sessionKey(storeName, key)
+	s.mu.Lock()
+	w, tracked := s.writes[sk]
+	s.mu.Unlock()
+
+	item, err := read()
+	if err != nil || !tracked {
+		return item, err
+	}
+
+	deadline := time.Now().Add(s.maxWait)
+	for !w.observedIn(item) {
+		if time.Now().After(deadline) {
+			return item, ErrSessionStaleRead
+		}
+		select {
+		case <-ctx.Done():
+			return item, ctx.Err()
+		case <-time.After(s.retryInterval):
+		}
+		if item, err = read(); err != nil {
+			return item, err
+		}
+	}
+
+	return item, nil
+}
+
+func (w sessionWrite) observedIn(item *StateItem) bool {
+	if w.deleted {
+		return item == nil || len(item.Value) == 0
+	}
+	if item == nil {
+		return false
+	}
+	sum := sha256.Sum256(item.Value)
+	return bytes.Equal(sum[:], w.sum[:])
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// laggingStateClient simulates an eventually consistent store: a saved value
+// only becomes visible after a number of reads.
+type laggingStateClient struct {
+	Client
+	mu      sync.Mutex
+	lag     int
+	visible map[string][]byte
+	pending map[string][]byte
+	reads   int
+	err     error
+}
+
+func newLaggingStateClient(lag int) *laggingStateClient {
+	return &laggingStateClient{
+		lag:     lag,
+		visible: make(map[string][]byte),
+		pending: make(map[string][]byte),
+	}
+}
+
+// write makes data pending for key, a nil data deleting it.
+func (c *laggingStateClient) write(key string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.pending[key] = data
+	c.reads = 0
+	return nil
+}
+
+func (c *laggingStateClient) read(key string) []byte {
+	if v, ok := c.pending[key]; ok && c.reads > c.lag {
+		c.visible[key] = v
+		delete(c.pending, key)
+	}
+	return c.visible[key]
+}
+
+func (c *laggingStateClient) SaveState(ctx context.Context, storeName, key string, data []byte, meta map[string]string, so ...StateOption) error {
+	return c.write(key, data)
+}
+
+func (c *laggingStateClient) SaveBulkState(ctx context.Context, storeName string, items ...*SetStateItem) error {
+	for _, item := range items {
+		if err := c.write(item.Key, item.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *laggingStateClient) SaveBulkStateChunked(ctx context.Context, storeName string, items []*SetStateItem, maxBytes int, opts ...StateOption) error {
+	return c.SaveBulkState(ctx, storeName, items...)
+}
+
+func (c *laggingStateClient) DeleteState(ctx context.Context, storeName, key string, meta map[string]string) error {
+	return c.write(key, nil)
+}
+
+func (c *laggingStateClient) DeleteStateWithETag(ctx context.Context, storeName, key string, etag *ETag, meta map[string]string, opts *StateOptions) error {
+	return c.write(key, nil)
+}
+
+func (c *laggingStateClient) DeleteBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string) error {
+	for _, key := range keys {
+		if err := c.write(key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *laggingStateClient) DeleteBulkStateItems(ctx context.Context, storeName string, items []*DeleteStateItem) error {
+	for _, item := range items {
+		if err := c.write(item.Key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *laggingStateClient) ExecuteStateTransaction(ctx context.Context, storeName string, meta map[string]string, ops []*StateOperation) error {
+	for _, op := range ops {
+		data := op.Item.Value
+		if op.Type == StateOperationTypeDelete {
+			data = nil
+		}
+		if err := c.write(op.Item.Key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *laggingStateClient) GetState(ctx context.Context, storeName, key string, meta map[string]string) (*StateItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reads++
+	return &StateItem{Key: key, Value: c.read(key)}, nil
+}
+
+func (c *laggingStateClient) GetStateWithConsistency(ctx context.Context, storeName, key string, meta map[string]string, sc StateConsistency) (*StateItem, error) {
+	return c.GetState(ctx, storeName, key, meta)
+}
+
+func (c *laggingStateClient) GetBulkState(ctx context.Context, storeName string, keys []string, meta map[string]string, parallelism int32) ([]*BulkStateItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reads++
+	items := make([]*BulkStateItem, len(keys))
+	for i, key := range keys {
+		items[i] = &BulkStateItem{Key: key, Value: c.read(key)}
+	}
+	return items, nil
+}
+
+func TestSessionClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries a stale read until the write is observed", func(t *testing.T) {
+		store := newLaggingStateClient(2)
+		s := NewSessionClient(store, WithSessionRetryInterval(time.Millisecond))
+		require.NoError(t, s.SaveState(ctx, testStore, "k1", []byte("v1"), nil))
+
+		item, err := s.GetState(ctx, testStore, "k1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), item.Value)
+		assert.Equal(t, 3, store.reads)
+	})
+
+	t.Run("returns stale read error after max wait", func(t *testing.T) {
+		store := newLaggingStateClient(1000)
+		s := NewSessionClient(store, WithSessionMaxWait(10*time.Millisecond), WithSessionRetryInterval(time.Millisecond))
+		require.NoError(t, s.SaveState(ctx, testStore, "k1", []byte("v1"), nil))
+
+		item, err := s.GetState(ctx, testStore, "k1", nil)
+		require.ErrorIs(t, err, ErrSessionStaleRead)
+		assert.Empty(t, item.Value)
+	})
+
+	t.Run("keeps tracking a write after it is observed", func(t *testing.T) {
+		store := newLaggingStateClient(2)
+		s := NewSessionClient(store, WithSessionRetryInterval(time.Millisecond))
+		require.NoError(t, s.SaveState(ctx, testStore, "k1", []byte("v1"), nil))
+
+		item, err := s.GetState(ctx, testStore, "k1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), item.Value)
+
+		// The next read hits a replica which hasn't applied the write yet.
+		store.mu.Lock()
+		store.visible["k1"] = []byte("v0")
+		store.pending["k1"] = []byte("v1")
+		store.reads = 0
+		store.mu.Unlock()
+		item, err = s.GetState(ctx, testStore, "k1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), item.Value)
+		assert.Equal(t, 3, store.reads)
+
+		// A newer write replaces the tracked one.
+		require.NoError(t, s.SaveState(ctx, testStore, "k1", []byte("v2"), nil))
+		item, err = s.GetState(ctx, testStore, "k1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), item.Value)
+	})
+
+	t.Run("untracked keys are read once", func(t *testing.T) {
+		store := newLaggingStateClient(0)
+		s := NewSessionClient(store)
+		_, err := s.GetState(ctx, testStore, "other", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, store.reads)
+	})
+
+	t.Run("tracks the writes of every write method", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			write func(s *SessionClient) error
+			want  []byte
+		}{
+			{"SaveBulkState", func(s *SessionClient) error {
+				return s.SaveBulkState(ctx, testStore, &SetStateItem{Key: "k1", Value: []byte("v2")})
+			}, []byte("v2")},
+			{"SaveBulkStateChunked", func(s *SessionClient) error {
+				return s.SaveBulkStateChunked(ctx, testStore, []*SetStateItem{{Key: "k1", Value: []byte("v2")}}, 1024)
+			}, []byte("v2")},
+			{"DeleteState", func(s *SessionClient) error {
+				return s.DeleteState(ctx, testStore, "k1", nil)
+			}, nil},
+			{"DeleteStateWithETag", func(s *SessionClient) error {
+				return s.DeleteStateWithETag(ctx, testStore, "k1", &ETag{Value: "1"}, nil, nil)
+			}, nil},
+			{"DeleteBulkState", func(s *SessionClient) error {
+				return s.DeleteBulkState(ctx, testStore, []string{"k1"}, nil)
+			}, nil},
+			{"DeleteBulkStateItems", func(s *SessionClient) error {
+				return s.DeleteBulkStateItems(ctx, testStore, []*DeleteStateItem{{Key: "k1"}})
+			}, nil},
+			{"ExecuteStateTransaction upsert", func(s *SessionClient) error {
+				return s.ExecuteStateTransaction(ctx, testStore, nil, []*StateOperation{
+					{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "k1", Value: []byte("v2")}},
+				})
+			}, []byte("v2")},
+			{"ExecuteStateTransaction delete", func(s *SessionClient) error {
+				return s.ExecuteStateTransaction(ctx, testStore, nil, []*StateOperation{
+					{Type: StateOperationTypeDelete, Item: &SetStateItem{Key: "k1"}},
+				})
+			}, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				store := newLaggingStateClient(2)
+				store.visible["k1"] = []byte("v1")
+				s := NewSessionClient(store, WithSessionRetryInterval(time.Millisecond))
+				require.NoError(t, tt.write(s))
+
+				item, err := s.GetState(ctx, testStore, "k1", nil)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, item.Value)
+				assert.Equal(t, 3, store.reads)
+			})
+		}
+	})
+
+	t.Run("forgets the keys of a failed write", func(t *testing.T) {
+		store := newLaggingStateClient(2)
+		s := NewSessionClient(store, WithSessionRetryInterval(time.Millisecond))
+		require.NoError(t, s.SaveState(ctx, testStore, "k1", []byte("v1"), nil))
+		store.err = errors.New("unavailable")
+		require.Error(t, s.SaveBulkState(ctx, testStore, &SetStateItem{Key: "k1", Value: []byte("v2")}))
+
+		_, err := s.GetState(ctx, testStore, "k1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, store.reads)
+	})
+
+	t.Run("retries a stale read with consistency", func(t *testing.T) {
+		store := newLaggingStateClient(2)
+		s := NewSessionClient(store, WithSessionRetryInterval(time.Millisecond))
+		require.NoError(t, s.SaveState(ctx, testStore, "k1", []byte("v1"), nil))
+
+		item, err := s.GetStateWithConsistency(ctx, testStore, "k1", nil, StateConsistencyStrong)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), item.Value)
+		assert.Equal(t, 3, store.reads)
+	})
+
+	t.Run("retries a stale bulk read until all writes are observed", func(t *testing.T) {
+		store := newLaggingStateClient(2)
+		s := NewSessionClient(store, WithSessionRetryInterval(time.Millisecond))
+		require.NoError(t, s.SaveBulkState(ctx, testStore,
+			&SetStateItem{Key: "k1", Value: []byte("v1")},
+			&SetStateItem{Key: "k2", Value: []byte("v2")}))
+
+		items, err := s.GetBulkState(ctx, testStore, []string{"k1", "k2", "other"}, nil, 0)
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		assert.Equal(t, []byte("v1"), items[0].Value)
+		assert.Equal(t, []byte("v2"), items[1].Value)
+		assert.Equal(t, 3, store.reads)
+
+		// The observed writes stay tracked, reads observing them aren't retried.
+		_, err = s.GetBulkState(ctx, testStore, []string{"k1", "k2"}, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 4, store.reads)
+	})
+
+	t.Run("returns stale bulk read error after max wait", func(t *testing.T) {
+		store := newLaggingStateClient(1000)
+		s := NewSessionClient(store, WithSessionMaxWait(10*time.Millisecond), WithSessionRetryInterval(time.Millisecond))
+		require.NoError(t, s.SaveState(ctx, testStore, "k1", []byte("v1"), nil))
+
+		items, err := s.GetBulkState(ctx, testStore, []string{"k1"}, nil, 0)
+		require.ErrorIs(t, err, ErrSessionStaleRead)
+		require.Len(t, items, 1)
+		assert.Empty(t, items[0].Value)
+	})
+
+	t.Run("against the test server", func(t *testing.T) {
+		s := NewSessionClient(testClient)
+		require.NoError(t, s.SaveState(ctx, testStore, "session-key", []byte("v1"), nil))
+		item, err := s.GetState(ctx, testStore, "session-key", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), item.Value)
+
+		require.NoError(t, s.DeleteState(ctx, testStore, "session-key", nil))
+		item, err = s.GetState(ctx, testStore, "session-key", nil)
+		require.NoError(t, err)
+		assert.Empty(t, item.Value)
+	})
+}
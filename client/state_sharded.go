@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const shardReplicasDefault = 128
+
+// ShardOption configures a ShardedStateClient.
+type ShardOption func(*ShardedStateClient)
+
+// WithShardReplicas sets the number of points each store has on the hash ring, defaults to 128.
+// More points spread the keys more evenly across the stores.
+func WithShardReplicas(n int) ShardOption {
+	return func(s *ShardedStateClient) {
+		s.replicas = n
+	}
+}
+
+type shardPoint struct {
+	hash  uint64
+	store string
+}
+
+// ShardedStateClient spreads keys across several state stores with consistent hashing.
+// Every key is routed to a single store, adding or removing a store only remaps the keys
+// of the ring segments it gains or loses, about 1/n of the keys for n stores.
+// Keys are not migrated when the stores change: the items of remapped keys stay in their
+// previous store and must be moved by the application.
+type ShardedStateClient struct {
+	client   Client
+	replicas int
+
+	mu     sync.RWMutex
+	stores []string
+	ring   []shardPoint
+}
+
+// NewShardedStateClient returns a ShardedStateClient routing keys across storeNames through c.
+func NewShardedStateClient(c Client, storeNames []string, opts ...ShardOption) (*ShardedStateClient, error) {
+	if c == nil {
+		return nil, errors.New("nil client")
+	}
+	if len(storeNames) == 0 {
+		return nil, errors.New("at least one state store required")
+	}
+	s := &ShardedStateClient{client: c, replicas: shardReplicasDefault}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.replicas < 1 {
+		return nil, fmt.Errorf("invalid shard replicas %d: must be at least 1", s.replicas)
+	}
+	for _, name := range storeNames {
+		if err := s.AddStore(name); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func shardHash(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// AddStore adds a state store to the ring.
+func (s *ShardedStateClient) AddStore(storeName string) error {
+	if storeName == "" {
+		return missingComponentName("state store")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.Contains(s.stores, storeName) {
+		return fmt.Errorf("state store %s already added", storeName)
+	}
+	s.stores = append(s.stores, storeName)
+	for i := 0; i < s.replicas; i++ {
+		s.ring = append(s.ring, shardPoint{hash: shardHash(storeName + "#" + strconv.Itoa(i)), store: storeName})
+	}
+	sort.Slice(s.ring, func(i, j int) bool {
+		if s.ring[i].hash != s.ring[j].hash {
+			return s.ring[i].hash < s.ring[j].hash
+		}
+		return s.ring[i].store < s.ring[j].store
+	})
+	return nil
+}
+
+// RemoveStore removes a state store from the ring, its keys are routed to the remaining stores.
+func (s *ShardedStateClient) RemoveStore(storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.Index(s.stores, storeName)
+	if i < 0 {
+		return fmt.Errorf("state store %s not found", storeName)
+	}
+	if len(s.stores) == 1 {
+		return errors.New("cannot remove the last state store")
+	}
+	s.stores = slices.Delete(s.stores, i, i+1)
+	s.ring = slices.DeleteFunc(s.ring, func(p shardPoint) bool {
+		return p.store == storeName
+	})
+	return nil
+}
+
+// Stores returns the state stores of the ring, in the order they were added.
+func (s *ShardedStateClient) Stores() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.stores)
+}
+
+// StoreFor returns the state store key is routed to.
+func (s *ShardedStateClient) StoreFor(key string) string {
+	h := shardHash(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := sort.Search(len(s.ring), func(i int) bool {
+		return s.ring[i].hash >= h
+	})
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].store
+}
+
+// GetState retrieves the state of key from the store it is routed to.
+func (s *ShardedStateClient) GetState(ctx context.Context, key string, meta map[string]string) (*StateItem, error) {
+	return s.client.GetState(ctx, s.StoreFor(key), key, meta)
+}
+
+// SaveState saves the state of key into the store it is routed to.
+func (s *ShardedStateClient) SaveState(ctx context.Context, key string, data []byte, meta map[string]string, so ...StateOption) error {
+	return s.client.SaveState(ctx, s.StoreFor(key), key, data, meta, so...)
+}
+
+// SaveStateWithETag saves the state of key with an ETag into the store it is routed to.
+func (s *ShardedStateClient) SaveStateWithETag(ctx context.Context, key string, data []byte, etag string, meta map[string]string, so ...StateOption) error {
+	return s.client.SaveStateWithETag(ctx, s.StoreFor(key), key, data, etag, meta, so...)
+}
+
+// DeleteState deletes the state of key from the store it is routed to.
+func (s *ShardedStateClient) DeleteState(ctx context.Context, key string, meta map[string]string) error {
+	return s.client.DeleteState(ctx, s.StoreFor(key), key, meta)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// storeRecordingClient records the store of every GetState, GetBulkState, SaveState, DeleteState
+// and ExecuteStateTransaction request.
+type storeRecordingClient struct {
+	pb.DaprClient
+	stores []string
+}
+
+func (c *storeRecordingClient) GetState(ctx context.Context, in *pb.GetStateRequest, opts ...grpc.CallOption) (*pb.GetStateResponse, error) {
+	c.stores = append(c.stores, in.GetStoreName())
+	return &pb.GetStateResponse{}, nil
+}
+
+func (c *storeRecordingClient) SaveState(ctx context.Context, in *pb.SaveStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.stores = append(c.stores, in.GetStoreName())
+	return &emptypb.Empty{}, nil
+}
+
+func (c *storeRecordingClient) DeleteState(ctx context.Context, in *pb.DeleteStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.stores = append(c.stores, in.GetStoreName())
+	return &emptypb.Empty{}, nil
+}
+
+func (c *storeRecordingClient) GetBulkState(ctx context.Context, in *pb.GetBulkStateRequest, opts ...grpc.CallOption) (*pb.GetBulkStateResponse, error) {
+	c.stores = append(c.stores, in.GetStoreName())
+	return &pb.GetBulkStateResponse{}, nil
+}
+
+func (c *storeRecordingClient) ExecuteStateTransaction(ctx context.Context, in *pb.ExecuteStateTransactionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.stores = append(c.stores, in.GetStoreName())
+	return &emptypb.Empty{}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestShardedStateClient$
+func TestShardedStateClient(t *testing.T) {
+	ctx := context.Background()
+	stores := []string{"store-a", "store-b", "store-c"}
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = "order-" + strconv.Itoa(i)
+	}
+	mapping := func(s *ShardedStateClient) map[string]string {
+		m := make(map[string]string, len(keys))
+		for _, k := range keys {
+			m[k] = s.StoreFor(k)
+		}
+		return m
+	}
+
+	t.Run("mapping is stable", func(t *testing.T) {
+		s1, err := NewShardedStateClient(testClient, stores)
+		require.NoError(t, err)
+		s2, err := NewShardedStateClient(testClient, []string{"store-c", "store-a", "store-b"})
+		require.NoError(t, err)
+		assert.Equal(t, mapping(s1), mapping(s2))
+		assert.Equal(t, "store-b", s1.StoreFor("order-1"), "the mapping must not change across releases")
+	})
+
+	t.Run("keys are distributed", func(t *testing.T) {
+		s, err := NewShardedStateClient(testClient, stores)
+		require.NoError(t, err)
+		counts := make(map[string]int)
+		for _, store := range mapping(s) {
+			counts[store]++
+		}
+		require.Len(t, counts, 3)
+		for store, n := range counts {
+			assert.InDelta(t, len(keys)/3, n, float64(len(keys))/10, store)
+		}
+	})
+
+	t.Run("adding and removing stores remaps few keys", func(t *testing.T) {
+		s, err := NewShardedStateClient(testClient, stores)
+		require.NoError(t, err)
+		before := mapping(s)
+
+		require.NoError(t, s.AddStore("store-d"))
+		added := mapping(s)
+		moved := 0
+		for k, store := range added {
+			if store != before[k] {
+				moved++
+				assert.Equal(t, "store-d", store, "keys only move to the new store")
+			}
+		}
+		assert.InDelta(t, len(keys)/4, moved, float64(len(keys))/10)
+
+		require.NoError(t, s.RemoveStore("store-d"))
+		assert.Equal(t, before, mapping(s))
+
+		require.NoError(t, s.RemoveStore("store-a"))
+		for k, store := range mapping(s) {
+			if before[k] != "store-a" {
+				assert.Equal(t, before[k], store, "keys of the remaining stores don't move")
+			}
+		}
+		assert.Equal(t, []string{"store-b", "store-c"}, s.Stores())
+	})
+
+	t.Run("calls are routed", func(t *testing.T) {
+		fake := &storeRecordingClient{}
+		s, err := NewShardedStateClient(&GRPCClient{protoClient: fake}, stores)
+		require.NoError(t, err)
+		for _, k := range keys[:10] {
+			_, err := s.GetState(ctx, k, nil)
+			require.NoError(t, err)
+			assert.Equal(t, s.StoreFor(k), fake.stores[len(fake.stores)-1])
+		}
+
+		sharded, err := NewShardedStateClient(testClient, stores)
+		require.NoError(t, err)
+		require.NoError(t, sharded.SaveState(ctx, "sharded-key", []byte(testData), nil))
+		item, err := sharded.GetState(ctx, "sharded-key", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(testData), item.Value)
+		require.NoError(t, sharded.DeleteState(ctx, "sharded-key", nil))
+	})
+
+	t.Run("invalid stores", func(t *testing.T) {
+		_, err := NewShardedStateClient(testClient, nil)
+		require.Error(t, err)
+		_, err = NewShardedStateClient(testClient, []string{"a", "a"})
+		require.Error(t, err)
+		_, err = NewShardedStateClient(testClient, []string{""})
+		require.ErrorIs(t, err, ErrMissingComponentName)
+		s, err := NewShardedStateClient(testClient, []string{"a"})
+		require.NoError(t, err)
+		require.Error(t, s.RemoveStore("a"))
+		require.Error(t, s.RemoveStore("b"))
+	})
+}
@@ -21,8 +21,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
 
 	v1 "github.com/dapr/dapr/pkg/proto/common/v1"
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 )
 
 const (
@@ -75,6 +78,35 @@ func TestStateOptionsConverter(t *testing.T) {
 	assert.Equal(t, v1.StateOptions_CONSISTENCY_STRONG, p.GetConsistency())
 }
 
+func TestStateMetadata(t *testing.T) {
+	t.Run("ttl expire time", func(t *testing.T) {
+		item := &StateItem{Metadata: map[string]string{"ttlExpireTime": "2024-03-01T10:00:00Z"}}
+		exp, ok := item.StateMetadata().TTLExpireTime()
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), exp)
+	})
+
+	t.Run("missing ttl", func(t *testing.T) {
+		item := &StateItem{}
+		exp, ok := item.StateMetadata().TTLExpireTime()
+		assert.False(t, ok)
+		assert.True(t, exp.IsZero())
+	})
+
+	t.Run("malformed ttl", func(t *testing.T) {
+		item := &BulkStateItem{Metadata: map[string]string{"ttlExpireTime": "tomorrow"}}
+		_, ok := item.StateMetadata().TTLExpireTime()
+		assert.False(t, ok)
+	})
+
+	t.Run("content type and raw values", func(t *testing.T) {
+		m := StateMetadata{"contentType": "application/json", "custom": "value"}
+		assert.Equal(t, "application/json", m.ContentType())
+		assert.Equal(t, "value", m.Get("custom"))
+		assert.Empty(t, m.Get("missing"))
+	})
+}
+
 // go test -timeout 30s ./client -count 1 -run ^TestSaveState$
 func TestSaveState(t *testing.T) {
 	ctx := context.Background()
@@ -116,6 +148,128 @@ func TestSaveState(t *testing.T) {
 	})
 }
 
+// go test -timeout 30s ./client -count 1 -run ^TestSaveStateWithTTLBounds$
+func TestSaveStateWithTTLBounds(t *testing.T) {
+	ctx := context.Background()
+	key := "key-ttl"
+	bounds := WithStateTTLBounds(10*time.Second, time.Hour)
+	t.Cleanup(func() {
+		require.NoError(t, testClient.DeleteState(ctx, testStore, key, nil))
+	})
+
+	t.Run("ttl at the minimum", func(t *testing.T) {
+		err := testClient.SaveState(ctx, testStore, key, []byte(testData), map[string]string{"ttlInSeconds": "10"}, bounds)
+		require.NoError(t, err)
+	})
+
+	t.Run("ttl at the maximum", func(t *testing.T) {
+		err := testClient.SaveState(ctx, testStore, key, []byte(testData), map[string]string{"ttlInSeconds": "3600"}, bounds)
+		require.NoError(t, err)
+	})
+
+	t.Run("ttl below the minimum", func(t *testing.T) {
+		err := testClient.SaveState(ctx, testStore, key, []byte(testData), map[string]string{"ttlInSeconds": "9"}, bounds)
+		require.ErrorContains(t, err, "below the minimum")
+	})
+
+	t.Run("ttl above the maximum", func(t *testing.T) {
+		err := testClient.SaveState(ctx, testStore, key, []byte(testData), map[string]string{"ttlInSeconds": "3601"}, bounds)
+		require.ErrorContains(t, err, "exceeds the maximum")
+	})
+
+	t.Run("ttl never expires with a maximum", func(t *testing.T) {
+		err := testClient.SaveState(ctx, testStore, key, []byte(testData), map[string]string{"ttlInSeconds": "-1"}, bounds)
+		require.Error(t, err)
+	})
+
+	t.Run("malformed ttl", func(t *testing.T) {
+		err := testClient.SaveState(ctx, testStore, key, []byte(testData), map[string]string{"ttlInSeconds": "soon"}, bounds)
+		require.Error(t, err)
+	})
+
+	t.Run("no ttl metadata", func(t *testing.T) {
+		err := testClient.SaveState(ctx, testStore, key, []byte(testData), nil, bounds)
+		require.NoError(t, err)
+	})
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestSaveStateChanged$
+func TestSaveStateChanged(t *testing.T) {
+	ctx := context.Background()
+	key := "key-changed"
+	t.Cleanup(func() {
+		require.NoError(t, testClient.DeleteState(ctx, testStore, key, nil))
+	})
+
+	changed, err := testClient.SaveStateChanged(ctx, testStore, key, []byte("v1"), nil, WithSkipUnchangedWrites())
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	t.Run("unchanged value is skipped", func(t *testing.T) {
+		changed, err := testClient.SaveStateChanged(ctx, testStore, key, []byte("v1"), nil, WithSkipUnchangedWrites())
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("changed value is written", func(t *testing.T) {
+		changed, err := testClient.SaveStateChanged(ctx, testStore, key, []byte("v2"), nil, WithSkipUnchangedWrites())
+		require.NoError(t, err)
+		assert.True(t, changed)
+		item, err := testClient.GetState(ctx, testStore, key, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), item.Value)
+	})
+
+	t.Run("unchanged value is written without the option", func(t *testing.T) {
+		changed, err := testClient.SaveStateChanged(ctx, testStore, key, []byte("v2"), nil)
+		require.NoError(t, err)
+		assert.True(t, changed)
+	})
+
+	t.Run("unchanged value with an etag or a ttl is written", func(t *testing.T) {
+		proto := &skipCheckStateClient{value: []byte("v1"), etag: "1"}
+		c := &GRPCClient{protoClient: proto}
+		require.NoError(t, c.SaveStateWithETag(ctx, testStore, key, []byte("v1"), "1", nil, WithSkipUnchangedWrites()))
+		changed, err := c.SaveStateChanged(ctx, testStore, key, []byte("v1"), map[string]string{"ttlInSeconds": "60"}, WithSkipUnchangedWrites())
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, 2, proto.saves)
+		assert.Zero(t, proto.gets)
+	})
+
+	t.Run("empty value of a missing key is written", func(t *testing.T) {
+		proto := &skipCheckStateClient{}
+		c := &GRPCClient{protoClient: proto}
+		changed, err := c.SaveStateChanged(ctx, testStore, key, []byte{}, map[string]string{"contentType": "text/plain"}, WithSkipUnchangedWrites())
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, 1, proto.saves)
+		assert.Equal(t, 1, proto.gets)
+		assert.Empty(t, proto.getMeta)
+	})
+}
+
+// skipCheckStateClient serves a single value with an ETag and counts the reads and saves.
+type skipCheckStateClient struct {
+	pb.DaprClient
+	value   []byte
+	etag    string
+	gets    int
+	saves   int
+	getMeta map[string]string
+}
+
+func (c *skipCheckStateClient) GetState(ctx context.Context, in *pb.GetStateRequest, opts ...grpc.CallOption) (*pb.GetStateResponse, error) {
+	c.gets++
+	c.getMeta = in.GetMetadata()
+	return &pb.GetStateResponse{Data: c.value, Etag: c.etag}, nil
+}
+
+func (c *skipCheckStateClient) SaveState(ctx context.Context, in *pb.SaveStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.saves++
+	return &emptypb.Empty{}, nil
+}
+
 // go test -timeout 30s ./client -count 1 -run ^TestDeleteState$
 func TestDeleteState(t *testing.T) {
 	ctx := context.Background()
@@ -355,6 +509,21 @@ func TestStateTransactions(t *testing.T) {
 		adds = append(adds, op)
 	}
 
+	t.Run("exec with empty store name", func(t *testing.T) {
+		err := testClient.ExecuteStateTransaction(ctx, "", meta, adds)
+		require.ErrorContains(t, err, "single state store")
+	})
+
+	t.Run("exec with malformed store name", func(t *testing.T) {
+		err := testClient.ExecuteStateTransaction(ctx, " "+store, meta, adds)
+		require.ErrorContains(t, err, "invalid state store name")
+	})
+
+	t.Run("exec with nil item", func(t *testing.T) {
+		err := testClient.ExecuteStateTransaction(ctx, store, meta, []*StateOperation{{Type: StateOperationTypeDelete}})
+		require.Error(t, err)
+	})
+
 	t.Run("exec inserts", func(t *testing.T) {
 		err := testClient.ExecuteStateTransaction(ctx, store, meta, adds)
 		require.NoError(t, err)
@@ -444,6 +613,104 @@ func TestQueryState(t *testing.T) {
 	})
 }
 
+func TestGetBulkStateAs(t *testing.T) {
+	ctx := context.Background()
+	type widget struct {
+		Name string `json:"name"`
+	}
+
+	err := testClient.SaveBulkState(ctx, testStore,
+		&SetStateItem{Key: "widget-1", Value: []byte(`{"name":"one"}`)},
+		&SetStateItem{Key: "widget-2", Value: []byte(`not json`)},
+	)
+	require.NoError(t, err)
+
+	t.Run("present, missing and undecodable values", func(t *testing.T) {
+		values, errs := GetBulkStateAs[widget](ctx, testClient, testStore, []string{"widget-1", "widget-2", "widget-missing"}, 2)
+		assert.Equal(t, map[string]widget{"widget-1": {Name: "one"}}, values)
+		require.Len(t, errs, 1)
+		require.Error(t, errs["widget-2"])
+		assert.NotContains(t, values, "widget-missing")
+	})
+
+	t.Run("request error is reported for every key", func(t *testing.T) {
+		values, errs := GetBulkStateAs[widget](ctx, testClient, "", []string{"widget-1"}, 1)
+		assert.Empty(t, values)
+		require.Error(t, errs["widget-1"])
+	})
+}
+
+func TestGetBulkStateAsSlice(t *testing.T) {
+	ctx := context.Background()
+	type widget struct {
+		Name string `json:"name"`
+	}
+
+	keys := []string{"slice-2", "slice-missing-1", "slice-bad", "slice-1", "slice-missing-2"}
+	err := testClient.SaveBulkState(ctx, testStore,
+		&SetStateItem{Key: "slice-1", Value: []byte(`{"name":"one"}`)},
+		&SetStateItem{Key: "slice-2", Value: []byte(`{"name":"two"}`)},
+		&SetStateItem{Key: "slice-bad", Value: []byte(`not json`)},
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, testClient.DeleteBulkState(ctx, testStore, []string{"slice-1", "slice-2", "slice-bad"}, nil))
+	})
+
+	t.Run("present, missing and undecodable values", func(t *testing.T) {
+		values, missing, err := GetBulkStateAsSlice[widget](ctx, testClient, testStore, keys, 2)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "slice-bad")
+		assert.Equal(t, []widget{{Name: "two"}, {Name: "one"}}, values)
+		assert.Equal(t, []string{"slice-missing-1", "slice-missing-2"}, missing)
+	})
+
+	t.Run("all present", func(t *testing.T) {
+		values, missing, err := GetBulkStateAsSlice[widget](ctx, testClient, testStore, []string{"slice-1", "slice-2"}, 1)
+		require.NoError(t, err)
+		assert.Len(t, values, 2)
+		assert.Empty(t, missing)
+	})
+
+	t.Run("request error", func(t *testing.T) {
+		_, _, err := GetBulkStateAsSlice[widget](ctx, testClient, "", keys, 1)
+		require.ErrorIs(t, err, ErrMissingComponentName)
+	})
+}
+
+func TestStateKeyValidation(t *testing.T) {
+	ctx := context.Background()
+	c := &GRPCClient{protoClient: testClient.(*GRPCClient).protoClient, stateKeyValidation: true}
+
+	t.Run("valid key", func(t *testing.T) {
+		require.NoError(t, c.SaveState(ctx, testStore, "valid-key|1", []byte(testData), nil))
+		_, err := c.GetState(ctx, testStore, "valid-key|1", nil)
+		require.NoError(t, err)
+		require.NoError(t, c.DeleteState(ctx, testStore, "valid-key|1", nil))
+	})
+
+	t.Run("reserved separator", func(t *testing.T) {
+		key := "app||key"
+		err := c.SaveState(ctx, testStore, key, []byte(testData), nil)
+		require.ErrorContains(t, err, `reserved separator "||"`)
+		_, err = c.GetState(ctx, testStore, key, nil)
+		require.Error(t, err)
+		_, err = c.GetBulkState(ctx, testStore, []string{"key", key}, nil, 1)
+		require.Error(t, err)
+		require.Error(t, c.DeleteState(ctx, testStore, key, nil))
+		require.Error(t, c.DeleteBulkState(ctx, testStore, []string{key}, nil))
+		require.Error(t, c.ExecuteStateTransaction(ctx, testStore, nil, []*StateOperation{
+			{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: key, Value: []byte(testData)}},
+		}))
+	})
+
+	t.Run("validation disabled by default", func(t *testing.T) {
+		key := "app||key"
+		require.NoError(t, testClient.SaveState(ctx, testStore, key, []byte(testData), nil))
+		require.NoError(t, testClient.DeleteState(ctx, testStore, key, nil))
+	})
+}
+
 func TestHasRequiredStateArgs(t *testing.T) {
 	t.Run("empty store should error", func(t *testing.T) {
 		err := hasRequiredStateArgs("", "key")
@@ -454,3 +721,52 @@ func TestHasRequiredStateArgs(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+// go test -timeout 30s ./client -count 1 -run ^TestDefaultStateStore$
+func TestDefaultStateStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("empty store name uses the default", func(t *testing.T) {
+		fake := &storeRecordingClient{}
+		c := &GRPCClient{protoClient: fake, defaultStateStore: "default-store"}
+		require.NoError(t, c.SaveState(ctx, "", "key1", []byte("v"), nil))
+		_, err := c.GetState(ctx, "", "key1", nil)
+		require.NoError(t, err)
+		require.NoError(t, c.DeleteState(ctx, "", "key1", nil))
+		assert.Equal(t, []string{"default-store", "default-store", "default-store"}, fake.stores)
+	})
+
+	t.Run("chunked saves and transactions use the default", func(t *testing.T) {
+		fake := &storeRecordingClient{}
+		c := &GRPCClient{protoClient: fake, defaultStateStore: "default-store"}
+		items := []*SetStateItem{{Key: "key1", Value: []byte("v")}}
+		require.NoError(t, c.SaveBulkStateChunked(ctx, "", items, 1024))
+		_, err := c.WithTransaction(ctx, "", []string{"key1"}, func(snapshot map[string][]byte) (map[string][]byte, []string, error) {
+			return map[string][]byte{"key1": []byte("v2")}, nil, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"default-store", "default-store", "default-store"}, fake.stores)
+	})
+
+	t.Run("explicit store name overrides the default", func(t *testing.T) {
+		fake := &storeRecordingClient{}
+		c := &GRPCClient{protoClient: fake, defaultStateStore: "default-store"}
+		require.NoError(t, c.SaveState(ctx, "other", "key1", []byte("v"), nil))
+		_, err := c.GetState(ctx, "other", "key1", nil)
+		require.NoError(t, err)
+		require.NoError(t, c.DeleteState(ctx, "other", "key1", nil))
+		assert.Equal(t, []string{"other", "other", "other"}, fake.stores)
+	})
+
+	t.Run("empty store name without default", func(t *testing.T) {
+		c := &GRPCClient{protoClient: &storeRecordingClient{}}
+		require.ErrorIs(t, c.SaveState(ctx, "", "key1", []byte("v"), nil), ErrMissingComponentName)
+		_, err := c.GetState(ctx, "", "key1", nil)
+		require.ErrorIs(t, err, ErrMissingComponentName)
+		require.ErrorIs(t, c.SaveBulkStateChunked(ctx, "", []*SetStateItem{{Key: "key1"}}, 1024), ErrMissingComponentName)
+		_, err = c.WithTransaction(ctx, "", []string{"key1"}, func(snapshot map[string][]byte) (map[string][]byte, []string, error) {
+			return nil, nil, nil
+		})
+		require.ErrorIs(t, err, ErrMissingComponentName)
+	})
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	transactionMaxRetriesDefault = 3
+	transactionBackoffDefault    = 50 * time.Millisecond
+)
+
+// ErrTransactionConflict is returned by WithTransaction when the keys kept being modified
+// concurrently and the retries are exhausted.
+var ErrTransactionConflict = errors.New("state transaction conflict")
+
+// TransactionFunc computes the writes and deletes of a WithTransaction call from a snapshot of the
+// keys read, keys missing from the store are absent from the snapshot. It may be called several
+// times when the commit conflicts with concurrent writes, so it must not have side effects.
+type TransactionFunc func(snapshot map[string][]byte) (writes map[string][]byte, deletes []string, err error)
+
+// StateConflictStats counts the ETag conflicts met by the optimistic state helpers of a client.
+type StateConflictStats struct {
+	// Conflicts is the number of commits which failed because a key was modified concurrently.
+	Conflicts uint64
+	// Exhausted is the number of calls which failed after exhausting their retries on conflicts.
+	Exhausted uint64
+}
+
+type stateConflictCounters struct {
+	conflicts atomic.Uint64
+	exhausted atomic.Uint64
+}
+
+// StateConflictStats returns the ETag conflicts met by WithTransaction since the client was created,
+// which helps tuning the retry budgets of optimistic updates.
+func (c *GRPCClient) StateConflictStats() StateConflictStats {
+	if c.stateConflicts == nil {
+		return StateConflictStats{}
+	}
+	return StateConflictStats{
+		Conflicts: c.stateConflicts.conflicts.Load(),
+		Exhausted: c.stateConflicts.exhausted.Load(),
+	}
+}
+
+// TransactionOption configures WithTransaction.
+type TransactionOption func(*transactionOptions)
+
+type transactionOptions struct {
+	maxRetries int
+	backoff    time.Duration
+	meta       map[string]string
+	onConflict func(key string, attempt int)
+	classify   StateErrorClassifier
+}
+
+// WithTransactionRetries sets how many times a conflicting or transiently failing transaction is retried, defaults to 3.
+func WithTransactionRetries(n int) TransactionOption {
+	return func(o *transactionOptions) {
+		o.maxRetries = n
+	}
+}
+
+// WithConflictCallback calls fn for every key written or deleted by a commit which failed with a conflict,
+// attempt being the number of the failed attempt starting at 1. State stores don't report which key
+// conflicted, so fn is called for all keys of the commit. fn is called before the retry.
+func WithConflictCallback(fn func(key string, attempt int)) TransactionOption {
+	return func(o *transactionOptions) {
+		o.onConflict = fn
+	}
+}
+
+// WithTransactionMetadata sets the metadata sent with the read and the transaction requests.
+func WithTransactionMetadata(meta map[string]string) TransactionOption {
+	return func(o *transactionOptions) {
+		o.meta = meta
+	}
+}
+
+// WithStateErrorClassifier overrides ClassifyStateError to decide how the state store errors of a transaction are handled.
+func WithStateErrorClassifier(classify StateErrorClassifier) TransactionOption {
+	return func(o *transactionOptions) {
+		o.classify = classify
+	}
+}
+
+// transactionStoreError is an error returned by the state store during a transaction,
+// keys are the keys of the commit when the commit failed.
+type transactionStoreError struct {
+	err  error
+	keys []string
+}
+
+func (e *transactionStoreError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transactionStoreError) Unwrap() error {
+	return e.err
+}
+
+// WithTransaction reads keys with their ETags, runs fn on the snapshot and commits the returned
+// writes and deletes atomically with ExecuteStateTransaction using first-write concurrency.
+// The state store errors are classified with ClassifyStateError: when a key was modified since it
+// was read, the whole read-compute-commit cycle is retried immediately and once the retries are
+// exhausted an error wrapping ErrTransactionConflict is returned; transient errors are retried with
+// an exponential backoff; other errors and errors returned by fn abort the transaction.
+// Writes and deletes are limited to keys, which are the only keys guarded by an ETag. Keys missing
+// when read have no ETag, whether their concurrent creation is detected depends on the state store.
+// The returned map is the state of keys as committed.
+func (c *GRPCClient) WithTransaction(ctx context.Context, storeName string, keys []string, fn TransactionFunc, opts ...TransactionOption) (map[string][]byte, error) {
+	storeName = c.stateStoreName(storeName)
+	if storeName == "" {
+		return nil, missingComponentName("state store")
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("keys required")
+	}
+	if fn == nil {
+		return nil, errors.New("transaction function required")
+	}
+	o := &transactionOptions{
+		maxRetries: transactionMaxRetriesDefault,
+		backoff:    transactionBackoffDefault,
+		classify:   ClassifyStateError,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var (
+		err   error
+		class StateErrorClass
+	)
+	backoff := o.backoff
+	for attempt := 0; attempt <= o.maxRetries; attempt++ {
+		var committed map[string][]byte
+		committed, err = c.runTransaction(ctx, storeName, keys, fn, o.meta)
+		if err == nil {
+			return committed, nil
+		}
+		var storeErr *transactionStoreError
+		if !errors.As(err, &storeErr) {
+			return nil, err
+		}
+		err = storeErr.err
+		switch class = o.classify(err); class {
+		case StateErrorConflict:
+			if c.stateConflicts != nil {
+				c.stateConflicts.conflicts.Add(1)
+			}
+			if o.onConflict != nil {
+				for _, k := range storeErr.keys {
+					o.onConflict(k, attempt+1)
+				}
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, errors.Join(err, ctxErr)
+			}
+		case StateErrorTransient:
+			if attempt == o.maxRetries {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return nil, errors.Join(err, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		default:
+			return nil, err
+		}
+	}
+	if class == StateErrorTransient {
+		return nil, fmt.Errorf("error executing transaction on store %s after %d retries: %w", storeName, o.maxRetries, err)
+	}
+	if c.stateConflicts != nil {
+		c.stateConflicts.exhausted.Add(1)
+	}
+	return nil, fmt.Errorf("%w on store %s after %d retries: %w", ErrTransactionConflict, storeName, o.maxRetries, err)
+}
+
+// runTransaction runs a single read-compute-commit cycle, the errors of the state store are
+// returned as *transactionStoreError.
+func (c *GRPCClient) runTransaction(ctx context.Context, storeName string, keys []string, fn TransactionFunc, meta map[string]string) (map[string][]byte, error) {
+	items, err := c.GetBulkState(ctx, storeName, keys, meta, 0)
+	if err != nil {
+		return nil, &transactionStoreError{err: err}
+	}
+	etags := make(map[string]string, len(keys))
+	snapshot := make(map[string][]byte, len(keys))
+	for _, item := range items {
+		if item.Error != "" {
+			return nil, fmt.Errorf("error reading key %s: %s", item.Key, item.Error)
+		}
+		etags[item.Key] = item.Etag
+		if len(item.Value) > 0 {
+			snapshot[item.Key] = item.Value
+		}
+	}
+
+	view := make(map[string][]byte, len(snapshot))
+	for k, v := range snapshot {
+		view[k] = bytes.Clone(v)
+	}
+	writes, deletes, err := fn(view)
+	if err != nil {
+		return nil, fmt.Errorf("transaction aborted: %w", err)
+	}
+
+	read := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		read[k] = true
+	}
+	ops := make([]*StateOperation, 0, len(writes)+len(deletes))
+	newItem := func(key string) *SetStateItem {
+		item := &SetStateItem{
+			Key:     key,
+			Options: &StateOptions{Concurrency: StateConcurrencyFirstWrite, Consistency: StateConsistencyStrong},
+		}
+		if etag := etags[key]; etag != "" {
+			item.Etag = &ETag{Value: etag}
+		}
+		return item
+	}
+	// Sort the writes so the operations are deterministic.
+	writeKeys := make([]string, 0, len(writes))
+	for k := range writes {
+		writeKeys = append(writeKeys, k)
+	}
+	sort.Strings(writeKeys)
+	for _, k := range writeKeys {
+		if !read[k] {
+			return nil, fmt.Errorf("transaction writes key %s which was not read", k)
+		}
+		item := newItem(k)
+		item.Value = writes[k]
+		ops = append(ops, &StateOperation{Type: StateOperationTypeUpsert, Item: item})
+	}
+	for _, k := range deletes {
+		if !read[k] {
+			return nil, fmt.Errorf("transaction deletes key %s which was not read", k)
+		}
+		ops = append(ops, &StateOperation{Type: StateOperationTypeDelete, Item: newItem(k)})
+	}
+
+	if err := c.ExecuteStateTransaction(ctx, storeName, meta, ops); err != nil {
+		opKeys := make([]string, len(ops))
+		for i, op := range ops {
+			opKeys[i] = op.Item.Key
+		}
+		return nil, &transactionStoreError{err: err, keys: opKeys}
+	}
+
+	for k, v := range writes {
+		snapshot[k] = v
+	}
+	for _, k := range deletes {
+		delete(snapshot, k)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+type etagValue struct {
+	data []byte
+	etag int
+}
+
+// etagStateClient is a state store enforcing first-write ETags in transactions.
+// beforeCommit runs before every transaction, which lets tests simulate concurrent writers.
+type etagStateClient struct {
+	pb.DaprClient
+	state        map[string]etagValue
+	commits      int
+	beforeCommit func(s *etagStateClient)
+	// commitErrs are returned by the first transactions, in order.
+	commitErrs []error
+}
+
+func (c *etagStateClient) set(key string, data []byte) {
+	c.state[key] = etagValue{data: data, etag: c.state[key].etag + 1}
+}
+
+func (c *etagStateClient) GetBulkState(ctx context.Context, in *pb.GetBulkStateRequest, opts ...grpc.CallOption) (*pb.GetBulkStateResponse, error) {
+	resp := &pb.GetBulkStateResponse{}
+	for _, k := range in.GetKeys() {
+		item := &pb.BulkStateItem{Key: k}
+		if v, ok := c.state[k]; ok {
+			item.Data = v.data
+			item.Etag = strconv.Itoa(v.etag)
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	return resp, nil
+}
+
+func (c *etagStateClient) ExecuteStateTransaction(ctx context.Context, in *pb.ExecuteStateTransactionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	if c.beforeCommit != nil {
+		c.beforeCommit(c)
+	}
+	c.commits++
+	if len(c.commitErrs) > 0 {
+		err := c.commitErrs[0]
+		c.commitErrs = c.commitErrs[1:]
+		return nil, err
+	}
+	for _, op := range in.GetOperations() {
+		req := op.GetRequest()
+		current, exists := c.state[req.GetKey()]
+		if etag := req.GetEtag().GetValue(); etag != "" && (!exists || etag != strconv.Itoa(current.etag)) {
+			return nil, status.Error(codes.Aborted, "possible etag mismatch")
+		}
+	}
+	for _, op := range in.GetOperations() {
+		req := op.GetRequest()
+		if op.GetOperationType() == "delete" {
+			delete(c.state, req.GetKey())
+			continue
+		}
+		c.set(req.GetKey(), req.GetValue())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func newEtagStateClient() *etagStateClient {
+	c := &etagStateClient{state: make(map[string]etagValue)}
+	c.set("from", []byte("10"))
+	c.set("to", []byte("0"))
+	c.set("temp", []byte("x"))
+	return c
+}
+
+// transfer moves one unit from "from" to "to" and deletes "temp".
+func transfer(snapshot map[string][]byte) (map[string][]byte, []string, error) {
+	from, err := strconv.Atoi(string(snapshot["from"]))
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := strconv.Atoi(string(snapshot["to"]))
+	if err != nil {
+		return nil, nil, err
+	}
+	writes := map[string][]byte{
+		"from": []byte(strconv.Itoa(from - 1)),
+		"to":   []byte(strconv.Itoa(to + 1)),
+	}
+	return writes, []string{"temp"}, nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestWithTransaction$
+func TestWithTransaction(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"from", "to", "temp"}
+
+	t.Run("commit", func(t *testing.T) {
+		fake := newEtagStateClient()
+		c := &GRPCClient{protoClient: fake}
+		committed, err := c.WithTransaction(ctx, testStore, keys, transfer)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"from": []byte("9"), "to": []byte("1")}, committed)
+		assert.Equal(t, []byte("9"), fake.state["from"].data)
+		assert.NotContains(t, fake.state, "temp")
+		assert.Equal(t, 1, fake.commits)
+	})
+
+	t.Run("conflict is retried", func(t *testing.T) {
+		fake := newEtagStateClient()
+		fake.beforeCommit = func(s *etagStateClient) {
+			if s.commits == 0 {
+				s.set("from", []byte("5"))
+			}
+		}
+		c := &GRPCClient{protoClient: fake}
+		committed, err := c.WithTransaction(ctx, testStore, keys, transfer)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("4"), committed["from"])
+		assert.Equal(t, []byte("4"), fake.state["from"].data)
+		assert.Equal(t, 2, fake.commits)
+	})
+
+	t.Run("retries are exhausted", func(t *testing.T) {
+		fake := newEtagStateClient()
+		fake.beforeCommit = func(s *etagStateClient) {
+			s.set("to", []byte("100"))
+		}
+		c := &GRPCClient{protoClient: fake}
+		_, err := c.WithTransaction(ctx, testStore, keys, transfer, WithTransactionRetries(2))
+		require.ErrorIs(t, err, ErrTransactionConflict)
+		assert.Equal(t, 3, fake.commits)
+		assert.Equal(t, []byte("10"), fake.state["from"].data)
+	})
+
+	t.Run("conflicts are counted and reported", func(t *testing.T) {
+		fake := newEtagStateClient()
+		fake.beforeCommit = func(s *etagStateClient) {
+			if s.commits < 2 {
+				s.set("from", []byte("5"))
+			}
+		}
+		c := &GRPCClient{protoClient: fake, stateConflicts: &stateConflictCounters{}}
+		var reported []string
+		var attempts []int
+		onConflict := WithConflictCallback(func(key string, attempt int) {
+			reported = append(reported, key)
+			attempts = append(attempts, attempt)
+		})
+		_, err := c.WithTransaction(ctx, testStore, keys, transfer, onConflict)
+		require.NoError(t, err)
+		assert.Equal(t, StateConflictStats{Conflicts: 2}, c.StateConflictStats())
+		assert.Equal(t, []string{"from", "to", "temp", "from", "to", "temp"}, reported)
+		assert.Equal(t, []int{1, 1, 1, 2, 2, 2}, attempts)
+
+		fake.beforeCommit = func(s *etagStateClient) {
+			s.set("to", []byte("100"))
+		}
+		_, err = c.WithTransaction(ctx, testStore, keys, transfer, WithTransactionRetries(1))
+		require.ErrorIs(t, err, ErrTransactionConflict)
+		assert.Equal(t, StateConflictStats{Conflicts: 4, Exhausted: 1}, c.StateConflictStats())
+	})
+
+	t.Run("transient errors are retried", func(t *testing.T) {
+		fake := newEtagStateClient()
+		fake.commitErrs = []error{status.Error(codes.Unavailable, "store unavailable")}
+		c := &GRPCClient{protoClient: fake}
+		committed, err := c.WithTransaction(ctx, testStore, keys, transfer)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("9"), committed["from"])
+		assert.Equal(t, 2, fake.commits)
+	})
+
+	t.Run("permanent errors fail fast", func(t *testing.T) {
+		fake := newEtagStateClient()
+		fake.commitErrs = []error{status.Error(codes.InvalidArgument, "invalid etag")}
+		c := &GRPCClient{protoClient: fake}
+		_, err := c.WithTransaction(ctx, testStore, keys, transfer)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrTransactionConflict)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, 1, fake.commits)
+	})
+
+	t.Run("classifier is overridable", func(t *testing.T) {
+		fake := newEtagStateClient()
+		fake.commitErrs = []error{status.Error(codes.Internal, "store busy")}
+		c := &GRPCClient{protoClient: fake}
+		classify := WithStateErrorClassifier(func(err error) StateErrorClass {
+			if status.Code(err) == codes.Internal {
+				return StateErrorConflict
+			}
+			return ClassifyStateError(err)
+		})
+		_, err := c.WithTransaction(ctx, testStore, keys, transfer, classify)
+		require.NoError(t, err)
+		assert.Equal(t, 2, fake.commits)
+	})
+
+	t.Run("fn error aborts", func(t *testing.T) {
+		fake := newEtagStateClient()
+		c := &GRPCClient{protoClient: fake}
+		errInsufficient := errors.New("insufficient funds")
+		_, err := c.WithTransaction(ctx, testStore, keys, func(map[string][]byte) (map[string][]byte, []string, error) {
+			return nil, nil, errInsufficient
+		})
+		require.ErrorIs(t, err, errInsufficient)
+		assert.Equal(t, 0, fake.commits)
+	})
+
+	t.Run("writes are limited to the keys read", func(t *testing.T) {
+		fake := newEtagStateClient()
+		c := &GRPCClient{protoClient: fake}
+		_, err := c.WithTransaction(ctx, testStore, []string{"from"}, func(map[string][]byte) (map[string][]byte, []string, error) {
+			return map[string][]byte{"to": []byte("1")}, nil, nil
+		})
+		require.Error(t, err)
+		assert.Equal(t, 0, fake.commits)
+	})
+}
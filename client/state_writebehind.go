@@ -0,0 +1,292 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	writeBehindIntervalDefault     = time.Second
+	writeBehindMaxKeysDefault      = 100
+	writeBehindFlushTimeoutDefault = 30 * time.Second
+	writeBehindErrorsBuffer        = 16
+)
+
+// ErrWriteBehindClosed is returned when saving state into a closed WriteBehindStateClient.
+var ErrWriteBehindClosed = errors.New("write-behind state client is closed")
+
+// WriteBehindFlushError is returned when a flush of a WriteBehindStateClient fails.
+// Keys holds the keys which weren't saved, they stay buffered and are retried by the next flush.
+type WriteBehindFlushError struct {
+	Err  error
+	Keys []string
+}
+
+func (e *WriteBehindFlushError) Error() string {
+	return fmt.Sprintf("error flushing %d buffered state keys: %v", len(e.Keys), e.Err)
+}
+
+func (e *WriteBehindFlushError) Unwrap() error {
+	return e.Err
+}
+
+// WriteBehindOption configures a WriteBehindStateClient.
+type WriteBehindOption func(*WriteBehindStateClient)
+
+// WithWriteBehindInterval sets how often the buffered values are flushed, defaults to 1s.
+func WithWriteBehindInterval(d time.Duration) WriteBehindOption {
+	return func(w *WriteBehindStateClient) {
+		w.interval = d
+	}
+}
+
+// WithWriteBehindMaxKeys flushes the buffer once it holds n keys, defaults to 100.
+func WithWriteBehindMaxKeys(n int) WriteBehindOption {
+	return func(w *WriteBehindStateClient) {
+		w.maxKeys = n
+	}
+}
+
+// WithWriteBehindFlushTimeout bounds each background flush to d, defaults to 30s.
+// Flush and Close are bounded by their context instead.
+func WithWriteBehindFlushTimeout(d time.Duration) WriteBehindOption {
+	return func(w *WriteBehindStateClient) {
+		w.flushTimeout = d
+	}
+}
+
+// WriteBehindStateClient buffers the saves of a state store and writes them behind, coalescing
+// the saves of a key into a single write of its latest value. The buffered values are flushed
+// with SaveBulkState every interval, when the buffer holds the maximum number of keys, and on
+// Flush and Close. Reads return the values not yet flushed, so a caller reads its own writes.
+// Saves are acknowledged before they reach the store: the buffered values are lost if the
+// process exits without calling Close. It is safe for concurrent use.
+type WriteBehindStateClient struct {
+	client       Client
+	storeName    string
+	interval     time.Duration
+	maxKeys      int
+	flushTimeout time.Duration
+
+	mu       sync.Mutex
+	pending  map[string]*SetStateItem
+	flushing map[string]*SetStateItem
+	// deleted holds the keys being deleted, whose values a failed flush must not buffer again.
+	deleted map[string]struct{}
+	closed  bool
+
+	// flushMu serializes the flushes, so at most one batch is in flight.
+	flushMu sync.Mutex
+	kick    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	errs    chan error
+}
+
+// NewWriteBehindStateClient returns a WriteBehindStateClient writing behind into storeName through c.
+func NewWriteBehindStateClient(c Client, storeName string, opts ...WriteBehindOption) (*WriteBehindStateClient, error) {
+	if c == nil {
+		return nil, errors.New("nil client")
+	}
+	if storeName == "" {
+		return nil, missingComponentName("state store")
+	}
+	w := &WriteBehindStateClient{
+		client:       c,
+		storeName:    storeName,
+		interval:     writeBehindIntervalDefault,
+		maxKeys:      writeBehindMaxKeysDefault,
+		flushTimeout: writeBehindFlushTimeoutDefault,
+		pending:      make(map[string]*SetStateItem),
+		deleted:      make(map[string]struct{}),
+		kick:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+		errs:         make(chan error, writeBehindErrorsBuffer),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.interval <= 0 {
+		return nil, fmt.Errorf("invalid write-behind interval %s: must be positive", w.interval)
+	}
+	if w.maxKeys < 1 {
+		return nil, fmt.Errorf("invalid write-behind max keys %d: must be at least 1", w.maxKeys)
+	}
+	if w.flushTimeout <= 0 {
+		return nil, fmt.Errorf("invalid write-behind flush timeout %s: must be positive", w.flushTimeout)
+	}
+	go w.run()
+	return w, nil
+}
+
+// Errors returns the channel receiving the errors of the background flushes, as *WriteBehindFlushError.
+// Errors are dropped when the channel is full. It is closed by Close.
+func (w *WriteBehindStateClient) Errors() <-chan error {
+	return w.errs
+}
+
+// SaveState buffers a copy of data and meta as the latest value of key, replacing the value buffered before.
+func (w *WriteBehindStateClient) SaveState(ctx context.Context, key string, data []byte, meta map[string]string, so ...StateOption) error {
+	if key == "" {
+		return errors.New("key required")
+	}
+	options := new(StateOptions)
+	for _, o := range so {
+		o(options)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrWriteBehindClosed
+	}
+	w.pending[key] = &SetStateItem{Key: key, Value: bytes.Clone(data), Metadata: maps.Clone(meta), Options: options}
+	delete(w.deleted, key)
+	if len(w.pending) >= w.maxKeys {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// GetState returns the buffered value of key, or reads it from the store when none is buffered.
+// Buffered values have no ETag.
+func (w *WriteBehindStateClient) GetState(ctx context.Context, key string, meta map[string]string) (*StateItem, error) {
+	w.mu.Lock()
+	item, ok := w.pending[key]
+	_, deleted := w.deleted[key]
+	if !ok && !deleted {
+		item, ok = w.flushing[key]
+	}
+	w.mu.Unlock()
+	if ok {
+		return &StateItem{Key: key, Value: item.Value, Metadata: item.Metadata}, nil
+	}
+	if deleted {
+		return &StateItem{Key: key}, nil
+	}
+	return w.client.GetState(ctx, w.storeName, key, meta)
+}
+
+// DeleteState discards the buffered value of key and deletes it from the store.
+// A flush in flight may still write the key, in which case the deletion is applied after it,
+// and the value isn't buffered again when that flush fails.
+func (w *WriteBehindStateClient) DeleteState(ctx context.Context, key string, meta map[string]string) error {
+	w.mu.Lock()
+	delete(w.pending, key)
+	delete(w.flushing, key)
+	w.deleted[key] = struct{}{}
+	w.mu.Unlock()
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	err := w.client.DeleteState(ctx, w.storeName, key, meta)
+	w.mu.Lock()
+	delete(w.deleted, key)
+	w.mu.Unlock()
+	return err
+}
+
+// Flush saves all buffered values and waits for the flush in flight, if any.
+// The error is returned as a *WriteBehindFlushError.
+func (w *WriteBehindStateClient) Flush(ctx context.Context) error {
+	return w.flush(ctx)
+}
+
+// Close stops the background flushes and flushes the remaining buffered values, further saves fail.
+func (w *WriteBehindStateClient) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+	close(w.stop)
+	<-w.done
+	close(w.errs)
+	return w.flush(ctx)
+}
+
+func (w *WriteBehindStateClient) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		case <-w.kick:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), w.flushTimeout)
+		err := w.flush(ctx)
+		cancel()
+		if err != nil {
+			select {
+			case w.errs <- err:
+			default:
+			}
+		}
+	}
+}
+
+func (w *WriteBehindStateClient) flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	if len(w.pending) == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	w.flushing, w.pending = w.pending, make(map[string]*SetStateItem)
+	keys := make([]string, 0, len(w.flushing))
+	for k := range w.flushing {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	items := make([]*SetStateItem, len(keys))
+	for i, k := range keys {
+		items[i] = w.flushing[k]
+	}
+	w.mu.Unlock()
+
+	err := w.client.SaveBulkState(ctx, w.storeName, items...)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flushing = nil
+	if err == nil {
+		return nil
+	}
+	// Keep the values which weren't saved, unless they were replaced or deleted meanwhile.
+	for _, item := range items {
+		_, replaced := w.pending[item.Key]
+		_, deleted := w.deleted[item.Key]
+		if !replaced && !deleted {
+			w.pending[item.Key] = item
+		}
+	}
+	return &WriteBehindFlushError{Err: err, Keys: keys}
+}
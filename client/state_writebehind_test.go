@@ -0,0 +1,292 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkSaveRecordingClient is a state store recording every SaveBulkState call.
+type bulkSaveRecordingClient struct {
+	Client
+	mu      sync.Mutex
+	state   map[string][]byte
+	batches [][]string
+	saveErr error
+	// started and release, when set, hold SaveBulkState back until release is closed or ctx is done.
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBulkSaveRecordingClient() *bulkSaveRecordingClient {
+	return &bulkSaveRecordingClient{state: make(map[string][]byte)}
+}
+
+func (c *bulkSaveRecordingClient) SaveBulkState(ctx context.Context, storeName string, items ...*SetStateItem) error {
+	c.mu.Lock()
+	started, release := c.started, c.release
+	c.mu.Unlock()
+	if release != nil {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.saveErr != nil {
+		return c.saveErr
+	}
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.Key)
+		c.state[item.Key] = item.Value
+	}
+	c.batches = append(c.batches, keys)
+	return nil
+}
+
+func (c *bulkSaveRecordingClient) GetState(ctx context.Context, storeName, key string, meta map[string]string) (*StateItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &StateItem{Key: key, Value: c.state[key], Etag: "1"}, nil
+}
+
+func (c *bulkSaveRecordingClient) DeleteState(ctx context.Context, storeName, key string, meta map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.state, key)
+	return nil
+}
+
+func (c *bulkSaveRecordingClient) batchCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.batches)
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestWriteBehindStateClient$
+func TestWriteBehindStateClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("saves of a key are coalesced", func(t *testing.T) {
+		fake := newBulkSaveRecordingClient()
+		w, err := NewWriteBehindStateClient(fake, testStore, WithWriteBehindInterval(time.Hour))
+		require.NoError(t, err)
+		defer w.Close(ctx)
+		for _, v := range []string{"1", "2", "3"} {
+			require.NoError(t, w.SaveState(ctx, "counter", []byte(v), nil))
+		}
+		require.NoError(t, w.SaveState(ctx, "other", []byte("x"), nil))
+		require.NoError(t, w.Flush(ctx))
+		assert.Equal(t, [][]string{{"counter", "other"}}, fake.batches)
+		assert.Equal(t, []byte("3"), fake.state["counter"])
+
+		require.NoError(t, w.Flush(ctx))
+		assert.Len(t, fake.batches, 1)
+	})
+
+	t.Run("reads see the buffered values", func(t *testing.T) {
+		fake := newBulkSaveRecordingClient()
+		fake.state["counter"] = []byte("0")
+		w, err := NewWriteBehindStateClient(fake, testStore, WithWriteBehindInterval(time.Hour))
+		require.NoError(t, err)
+		defer w.Close(ctx)
+
+		item, err := w.GetState(ctx, "counter", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("0"), item.Value)
+
+		require.NoError(t, w.SaveState(ctx, "counter", []byte("1"), nil))
+		item, err = w.GetState(ctx, "counter", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("1"), item.Value)
+		assert.Empty(t, item.Etag)
+		assert.Equal(t, []byte("0"), fake.state["counter"])
+
+		require.NoError(t, w.Flush(ctx))
+		item, err = w.GetState(ctx, "counter", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("1"), item.Value)
+		assert.Equal(t, "1", item.Etag)
+	})
+
+	t.Run("deletes discard the buffered value", func(t *testing.T) {
+		fake := newBulkSaveRecordingClient()
+		w, err := NewWriteBehindStateClient(fake, testStore, WithWriteBehindInterval(time.Hour))
+		require.NoError(t, err)
+		defer w.Close(ctx)
+		require.NoError(t, w.SaveState(ctx, "counter", []byte("1"), nil))
+		require.NoError(t, w.DeleteState(ctx, "counter", nil))
+		item, err := w.GetState(ctx, "counter", nil)
+		require.NoError(t, err)
+		assert.Empty(t, item.Value)
+		require.NoError(t, w.Flush(ctx))
+		assert.Empty(t, fake.batches)
+	})
+
+	t.Run("saves are buffered as copies", func(t *testing.T) {
+		fake := newBulkSaveRecordingClient()
+		w, err := NewWriteBehindStateClient(fake, testStore, WithWriteBehindInterval(time.Hour))
+		require.NoError(t, err)
+		defer w.Close(ctx)
+		data := []byte("v1")
+		meta := map[string]string{"contentType": "text/plain"}
+		require.NoError(t, w.SaveState(ctx, "key1", data, meta))
+		data[1] = '2'
+		meta["contentType"] = "application/json"
+
+		item, err := w.GetState(ctx, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), item.Value)
+		assert.Equal(t, "text/plain", item.Metadata["contentType"])
+		require.NoError(t, w.Flush(ctx))
+		assert.Equal(t, []byte("v1"), fake.state["key1"])
+	})
+
+	t.Run("deletes win over a failed flush in flight", func(t *testing.T) {
+		fake := newBulkSaveRecordingClient()
+		fake.state["key1"] = []byte("v0")
+		fake.saveErr = errors.New("store unavailable")
+		fake.started = make(chan struct{}, 1)
+		fake.release = make(chan struct{})
+		w, err := NewWriteBehindStateClient(fake, testStore, WithWriteBehindInterval(time.Hour))
+		require.NoError(t, err)
+		defer w.Close(ctx)
+		require.NoError(t, w.SaveState(ctx, "key1", []byte("v1"), nil))
+
+		flushed := make(chan error, 1)
+		go func() { flushed <- w.Flush(ctx) }()
+		<-fake.started
+		deleted := make(chan error, 1)
+		go func() { deleted <- w.DeleteState(ctx, "key1", nil) }()
+		require.Eventually(t, func() bool {
+			item, err := w.GetState(ctx, "key1", nil)
+			return err == nil && item.Value == nil
+		}, 5*time.Second, time.Millisecond)
+
+		close(fake.release)
+		require.Error(t, <-flushed)
+		require.NoError(t, <-deleted)
+		item, err := w.GetState(ctx, "key1", nil)
+		require.NoError(t, err)
+		assert.Empty(t, item.Value)
+
+		fake.mu.Lock()
+		fake.saveErr = nil
+		fake.release = nil
+		fake.mu.Unlock()
+		require.NoError(t, w.Flush(ctx))
+		assert.Empty(t, fake.batches)
+		assert.NotContains(t, fake.state, "key1")
+	})
+
+	t.Run("background flushes time out", func(t *testing.T) {
+		fake := newBulkSaveRecordingClient()
+		fake.started = make(chan struct{}, 1)
+		fake.release = make(chan struct{})
+		w, err := NewWriteBehindStateClient(fake, testStore,
+			WithWriteBehindInterval(10*time.Millisecond), WithWriteBehindFlushTimeout(10*time.Millisecond))
+		require.NoError(t, err)
+		require.NoError(t, w.SaveState(ctx, "key1", []byte("v"), nil))
+
+		select {
+		case err := <-w.Errors():
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no flush error reported")
+		}
+		close(fake.release)
+		require.NoError(t, w.Close(ctx))
+	})
+
+	t.Run("flushes on interval and key count", func(t *testing.T) {
+		fake := newBulkSaveRecordingClient()
+		w, err := NewWriteBehindStateClient(fake, testStore, WithWriteBehindInterval(10*time.Millisecond))
+		require.NoError(t, err)
+		require.NoError(t, w.SaveState(ctx, "key1", []byte("v"), nil))
+		require.Eventually(t, func() bool { return fake.batchCount() == 1 }, 5*time.Second, 5*time.Millisecond)
+		require.NoError(t, w.Close(ctx))
+
+		fake = newBulkSaveRecordingClient()
+		w, err = NewWriteBehindStateClient(fake, testStore, WithWriteBehindInterval(time.Hour), WithWriteBehindMaxKeys(2))
+		require.NoError(t, err)
+		defer w.Close(ctx)
+		require.NoError(t, w.SaveState(ctx, "key1", []byte("v"), nil))
+		require.NoError(t, w.SaveState(ctx, "key2", []byte("v"), nil))
+		require.Eventually(t, func() bool { return fake.batchCount() == 1 }, 5*time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("failed flushes are reported and retried", func(t *testing.T) {
+		fake := newBulkSaveRecordingClient()
+		fake.saveErr = errors.New("store unavailable")
+		w, err := NewWriteBehindStateClient(fake, testStore, WithWriteBehindInterval(10*time.Millisecond))
+		require.NoError(t, err)
+		require.NoError(t, w.SaveState(ctx, "key1", []byte("v"), nil))
+
+		var flushErr *WriteBehindFlushError
+		select {
+		case err := <-w.Errors():
+			require.ErrorAs(t, err, &flushErr)
+			assert.Equal(t, []string{"key1"}, flushErr.Keys)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no flush error reported")
+		}
+		item, err := w.GetState(ctx, "key1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v"), item.Value)
+
+		fake.mu.Lock()
+		fake.saveErr = nil
+		fake.mu.Unlock()
+		require.NoError(t, w.Close(ctx))
+		assert.Equal(t, []byte("v"), fake.state["key1"])
+	})
+
+	t.Run("closed client rejects saves", func(t *testing.T) {
+		fake := newBulkSaveRecordingClient()
+		w, err := NewWriteBehindStateClient(fake, testStore)
+		require.NoError(t, err)
+		require.NoError(t, w.SaveState(ctx, "key1", []byte("v"), nil))
+		require.NoError(t, w.Close(ctx))
+		assert.Equal(t, [][]string{{"key1"}}, fake.batches)
+		require.ErrorIs(t, w.SaveState(ctx, "key1", []byte("v"), nil), ErrWriteBehindClosed)
+		require.NoError(t, w.Close(ctx))
+		_, open := <-w.Errors()
+		assert.False(t, open)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewWriteBehindStateClient(newBulkSaveRecordingClient(), "")
+		require.ErrorIs(t, err, ErrMissingComponentName)
+		_, err = NewWriteBehindStateClient(newBulkSaveRecordingClient(), testStore, WithWriteBehindInterval(0))
+		require.Error(t, err)
+		_, err = NewWriteBehindStateClient(newBulkSaveRecordingClient(), testStore, WithWriteBehindMaxKeys(0))
+		require.Error(t, err)
+		_, err = NewWriteBehindStateClient(newBulkSaveRecordingClient(), testStore, WithWriteBehindFlushTimeout(0))
+		require.Error(t, err)
+	})
+}
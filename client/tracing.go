@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	traceAttrStateStore   = "dapr.state.store"
+	traceAttrStateUpserts = "dapr.state.transaction.upserts"
+	traceAttrStateDeletes = "dapr.state.transaction.deletes"
+	traceAttrStateKeys    = "dapr.state.transaction.keys"
+)
+
+// traceKeysMode controls whether state keys are recorded on trace spans.
+type traceKeysMode int
+
+const (
+	traceKeysNone traceKeysMode = iota
+	traceKeysRaw
+	traceKeysRedacted
+)
+
+// traceStateTransaction records the shape of a state transaction on the span in ctx, if it is recording.
+func (c *GRPCClient) traceStateTransaction(ctx context.Context, storeName string, ops []*StateOperation) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	var upserts, deletes int
+	keys := make([]string, 0, len(ops))
+	for _, op := range ops {
+		switch op.Type {
+		case StateOperationTypeUpsert:
+			upserts++
+		case StateOperationTypeDelete:
+			deletes++
+		}
+		keys = append(keys, op.Item.Key)
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String(traceAttrStateStore, storeName),
+		attribute.Int(traceAttrStateUpserts, upserts),
+		attribute.Int(traceAttrStateDeletes, deletes),
+	}
+	switch c.traceStateKeys {
+	case traceKeysRaw:
+		attrs = append(attrs, attribute.StringSlice(traceAttrStateKeys, keys))
+	case traceKeysRedacted:
+		for i, k := range keys {
+			keys[i] = redactTraceKey(k)
+		}
+		attrs = append(attrs, attribute.StringSlice(traceAttrStateKeys, keys))
+	case traceKeysNone:
+	}
+	span.SetAttributes(attrs...)
+}
+
+// redactTraceKey replaces a key with a short stable hash so equal keys can still be correlated.
+func redactTraceKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
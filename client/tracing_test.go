@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+// recordingSpan is a trace.Span capturing the attributes set on it.
+type recordingSpan struct {
+	trace.Span
+	attrs map[attribute.Key]attribute.Value
+}
+
+func newRecordingSpan() *recordingSpan {
+	return &recordingSpan{
+		Span:  trace.SpanFromContext(context.Background()),
+		attrs: make(map[attribute.Key]attribute.Value),
+	}
+}
+
+func (s *recordingSpan) IsRecording() bool { return true }
+
+func (s *recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+type noopTransactionClient struct {
+	pb.DaprClient
+}
+
+func (c *noopTransactionClient) ExecuteStateTransaction(context.Context, *pb.ExecuteStateTransactionRequest, ...grpc.CallOption) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func TestStateTransactionTracing(t *testing.T) {
+	ops := []*StateOperation{
+		{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "k1", Value: []byte("v1")}},
+		{Type: StateOperationTypeUpsert, Item: &SetStateItem{Key: "k2", Value: []byte("v2")}},
+		{Type: StateOperationTypeDelete, Item: &SetStateItem{Key: "k3"}},
+	}
+
+	t.Run("records operation counts without keys by default", func(t *testing.T) {
+		span := newRecordingSpan()
+		ctx := trace.ContextWithSpan(context.Background(), span)
+		c := &GRPCClient{protoClient: &noopTransactionClient{}}
+		require.NoError(t, c.ExecuteStateTransaction(ctx, testStore, nil, ops))
+		assert.Equal(t, testStore, span.attrs[traceAttrStateStore].AsString())
+		assert.Equal(t, int64(2), span.attrs[traceAttrStateUpserts].AsInt64())
+		assert.Equal(t, int64(1), span.attrs[traceAttrStateDeletes].AsInt64())
+		assert.NotContains(t, span.attrs, attribute.Key(traceAttrStateKeys))
+	})
+
+	t.Run("records keys when enabled", func(t *testing.T) {
+		span := newRecordingSpan()
+		ctx := trace.ContextWithSpan(context.Background(), span)
+		c := &GRPCClient{protoClient: &noopTransactionClient{}, traceStateKeys: traceKeysRaw}
+		require.NoError(t, c.ExecuteStateTransaction(ctx, testStore, nil, ops))
+		assert.Equal(t, []string{"k1", "k2", "k3"}, span.attrs[traceAttrStateKeys].AsStringSlice())
+	})
+
+	t.Run("records redacted keys", func(t *testing.T) {
+		span := newRecordingSpan()
+		ctx := trace.ContextWithSpan(context.Background(), span)
+		c := &GRPCClient{protoClient: &noopTransactionClient{}, traceStateKeys: traceKeysRedacted}
+		require.NoError(t, c.ExecuteStateTransaction(ctx, testStore, nil, ops))
+		keys := span.attrs[traceAttrStateKeys].AsStringSlice()
+		require.Len(t, keys, 3)
+		assert.Equal(t, redactTraceKey("k1"), keys[0])
+		assert.NotContains(t, keys, "k1")
+	})
+
+	t.Run("option enables key recording", func(t *testing.T) {
+		o := &clientOptions{}
+		WithTraceStateKeys(true)(o)
+		assert.Equal(t, traceKeysRedacted, o.traceStateKeys)
+	})
+}
@@ -13,7 +13,20 @@ limitations under the License.
 
 package client
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrMissingComponentName is returned when a call requiring a component name, such as the name of
+// a state store, pub/sub or secret store, is made with an empty name.
+var ErrMissingComponentName = errors.New("component name required")
+
+// missingComponentName returns an error wrapping ErrMissingComponentName for a component of kind.
+func missingComponentName(kind string) error {
+	return fmt.Errorf("%w: set the name of the %s component as declared in its metadata.name", ErrMissingComponentName, kind)
+}
 
 // isCloudEvent returns true if the event is a CloudEvent.
 // An event is a CloudEvent if it `id`, `source`, `specversion` and `type` fields.
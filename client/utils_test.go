@@ -14,13 +14,65 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"errors"
 	"io"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
+// go test -timeout 30s ./client -count 1 -run ^TestMissingComponentName$
+func TestMissingComponentName(t *testing.T) {
+	ctx := context.Background()
+	calls := map[string]func() error{
+		"SaveState": func() error {
+			return testClient.SaveState(ctx, "", "key1", []byte(testData), nil)
+		},
+		"GetState": func() error {
+			_, err := testClient.GetState(ctx, "", "key1", nil)
+			return err
+		},
+		"GetBulkState": func() error {
+			_, err := testClient.GetBulkState(ctx, "", []string{"key1"}, nil, 1)
+			return err
+		},
+		"DeleteState": func() error {
+			return testClient.DeleteState(ctx, "", "key1", nil)
+		},
+		"ExecuteStateTransaction": func() error {
+			return testClient.ExecuteStateTransaction(ctx, "", nil, nil)
+		},
+		"QueryStateAlpha1": func() error {
+			_, err := testClient.QueryStateAlpha1(ctx, "", "{}", nil)
+			return err
+		},
+		"PublishEvent": func() error {
+			return testClient.PublishEvent(ctx, "", "topic", []byte("ping"))
+		},
+		"PublishEvents": func() error {
+			return testClient.PublishEvents(ctx, "", "topic", []interface{}{"ping"}).Error
+		},
+		"GetSecret": func() error {
+			_, err := testClient.GetSecret(ctx, "", "key1", nil)
+			return err
+		},
+		"GetBulkSecret": func() error {
+			_, err := testClient.GetBulkSecret(ctx, "", nil)
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			err := call()
+			require.ErrorIs(t, err, ErrMissingComponentName)
+			require.ErrorContains(t, err, "metadata.name")
+		})
+	}
+}
+
 func TestIsCloudEvent(t *testing.T) {
 	testcases := []struct {
 		name     string
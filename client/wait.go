@@ -36,7 +36,9 @@ func (c *GRPCClient) Wait(ctx context.Context, timeout time.Duration) error {
 	// the code here, we rely on GRPCs connectivity state management instead.
 	// See https://github.com/grpc/grpc/blob/master/doc/connectivity-semantics-and-api.md
 	for {
-		curState := c.connection.GetState()
+		// The connection is looked up on every iteration, as a reset of the pool replaces it.
+		conn := c.conn()
+		curState := conn.GetState()
 		if curState == connectivity.Ready {
 			return nil
 		}
@@ -46,7 +48,7 @@ func (c *GRPCClient) Wait(ctx context.Context, timeout time.Duration) error {
 			return errWaitTimedOut
 		default:
 			// Multiple state changes can happen: keep waiting for a successful one or time out
-			c.connection.WaitForStateChange(timeoutCtx, curState)
+			conn.WaitForStateChange(timeoutCtx, curState)
 		}
 	}
 }
@@ -111,13 +111,13 @@ func (c *GRPCClient) StartWorkflowBeta1(ctx context.Context, req *StartWorkflowR
 		}
 	}
 
-	resp, err := c.protoClient.StartWorkflowBeta1(ctx, &pb.StartWorkflowRequest{
+	resp, err := callDapr(ctx, c, "StartWorkflowBeta1", &pb.StartWorkflowRequest{
 		InstanceId:        req.InstanceID,
 		WorkflowComponent: req.WorkflowComponent,
 		WorkflowName:      req.WorkflowName,
 		Options:           req.Options,
 		Input:             input,
-	})
+	}, c.protoClient.StartWorkflowBeta1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start workflow instance: %v", err)
 	}
@@ -134,10 +134,10 @@ func (c *GRPCClient) GetWorkflowBeta1(ctx context.Context, req *GetWorkflowReque
 	if req.WorkflowComponent == "" {
 		req.WorkflowComponent = DefaultWorkflowComponent
 	}
-	resp, err := c.protoClient.GetWorkflowBeta1(ctx, &pb.GetWorkflowRequest{
+	resp, err := callDapr(ctx, c, "GetWorkflowBeta1", &pb.GetWorkflowRequest{
 		InstanceId:        req.InstanceID,
 		WorkflowComponent: req.WorkflowComponent,
-	})
+	}, c.protoClient.GetWorkflowBeta1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get workflow status: %v", err)
 	}
@@ -159,10 +159,10 @@ func (c *GRPCClient) PurgeWorkflowBeta1(ctx context.Context, req *PurgeWorkflowR
 	if req.WorkflowComponent == "" {
 		req.WorkflowComponent = DefaultWorkflowComponent
 	}
-	_, err := c.protoClient.PurgeWorkflowBeta1(ctx, &pb.PurgeWorkflowRequest{
+	_, err := callDapr(ctx, c, "PurgeWorkflowBeta1", &pb.PurgeWorkflowRequest{
 		InstanceId:        req.InstanceID,
 		WorkflowComponent: req.WorkflowComponent,
-	})
+	}, c.protoClient.PurgeWorkflowBeta1)
 	if err != nil {
 		return fmt.Errorf("failed to purge workflow: %v", err)
 	}
@@ -177,10 +177,10 @@ func (c *GRPCClient) TerminateWorkflowBeta1(ctx context.Context, req *TerminateW
 	if req.WorkflowComponent == "" {
 		req.WorkflowComponent = DefaultWorkflowComponent
 	}
-	_, err := c.protoClient.TerminateWorkflowBeta1(ctx, &pb.TerminateWorkflowRequest{
+	_, err := callDapr(ctx, c, "TerminateWorkflowBeta1", &pb.TerminateWorkflowRequest{
 		InstanceId:        req.InstanceID,
 		WorkflowComponent: req.WorkflowComponent,
-	})
+	}, c.protoClient.TerminateWorkflowBeta1)
 	if err != nil {
 		return fmt.Errorf("failed to terminate workflow: %v", err)
 	}
@@ -195,10 +195,10 @@ func (c *GRPCClient) PauseWorkflowBeta1(ctx context.Context, req *PauseWorkflowR
 	if req.WorkflowComponent == "" {
 		req.WorkflowComponent = DefaultWorkflowComponent
 	}
-	_, err := c.protoClient.PauseWorkflowBeta1(ctx, &pb.PauseWorkflowRequest{
+	_, err := callDapr(ctx, c, "PauseWorkflowBeta1", &pb.PauseWorkflowRequest{
 		InstanceId:        req.InstanceID,
 		WorkflowComponent: req.WorkflowComponent,
-	})
+	}, c.protoClient.PauseWorkflowBeta1)
 	if err != nil {
 		return fmt.Errorf("failed to pause workflow: %v", err)
 	}
@@ -213,10 +213,10 @@ func (c *GRPCClient) ResumeWorkflowBeta1(ctx context.Context, req *ResumeWorkflo
 	if req.WorkflowComponent == "" {
 		req.WorkflowComponent = DefaultWorkflowComponent
 	}
-	_, err := c.protoClient.ResumeWorkflowBeta1(ctx, &pb.ResumeWorkflowRequest{
+	_, err := callDapr(ctx, c, "ResumeWorkflowBeta1", &pb.ResumeWorkflowRequest{
 		InstanceId:        req.InstanceID,
 		WorkflowComponent: req.WorkflowComponent,
-	})
+	}, c.protoClient.ResumeWorkflowBeta1)
 	if err != nil {
 		return fmt.Errorf("failed to resume workflow: %v", err)
 	}
@@ -248,12 +248,12 @@ func (c *GRPCClient) RaiseEventWorkflowBeta1(ctx context.Context, req *RaiseEven
 		}
 	}
 
-	_, err = c.protoClient.RaiseEventWorkflowBeta1(ctx, &pb.RaiseEventWorkflowRequest{
+	_, err = callDapr(ctx, c, "RaiseEventWorkflowBeta1", &pb.RaiseEventWorkflowRequest{
 		InstanceId:        req.InstanceID,
 		WorkflowComponent: req.WorkflowComponent,
 		EventName:         req.EventName,
 		EventData:         eventData,
-	})
+	}, c.protoClient.RaiseEventWorkflowBeta1)
 	if err != nil {
 		return fmt.Errorf("failed to raise event on workflow: %v", err)
 	}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const workflowPollIntervalDefault = 500 * time.Millisecond
+
+// workflowTerminalStatuses are the runtime statuses of workflows which won't make progress anymore.
+var workflowTerminalStatuses = []string{"COMPLETED", "FAILED", "TERMINATED", "CANCELED"}
+
+// StartWorkflowOption configures StartWorkflowAs.
+type StartWorkflowOption func(*startWorkflowOptions)
+
+type startWorkflowOptions struct {
+	instanceID   string
+	options      map[string]string
+	pollInterval time.Duration
+}
+
+// WithWorkflowInstanceID sets the instance ID of the started workflow, a random ID is generated by default.
+func WithWorkflowInstanceID(id string) StartWorkflowOption {
+	return func(o *startWorkflowOptions) {
+		o.instanceID = id
+	}
+}
+
+// WithWorkflowOptions sets the metadata sent with the start request.
+func WithWorkflowOptions(options map[string]string) StartWorkflowOption {
+	return func(o *startWorkflowOptions) {
+		o.options = options
+	}
+}
+
+// WithWorkflowPollInterval sets how often WaitForCompletion of the returned handle polls the workflow status, defaults to 500ms.
+func WithWorkflowPollInterval(d time.Duration) StartWorkflowOption {
+	return func(o *startWorkflowOptions) {
+		o.pollInterval = d
+	}
+}
+
+// WorkflowHandle is a started workflow instance, its methods operate on that instance.
+type WorkflowHandle struct {
+	InstanceID string
+
+	client       Client
+	component    string
+	pollInterval time.Duration
+}
+
+// StartWorkflowAs starts workflowName with input encoded as JSON and returns a handle to the started instance.
+// An empty workflowComponent uses DefaultWorkflowComponent.
+func StartWorkflowAs[In any](ctx context.Context, c Client, workflowComponent, workflowName string, input In, opts ...StartWorkflowOption) (*WorkflowHandle, error) {
+	o := &startWorkflowOptions{pollInterval: workflowPollIntervalDefault}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid workflow poll interval %s: must be positive", o.pollInterval)
+	}
+	if workflowComponent == "" {
+		workflowComponent = DefaultWorkflowComponent
+	}
+	data, err := marshalInput(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start workflow: %w", err)
+	}
+	resp, err := c.StartWorkflowBeta1(ctx, &StartWorkflowRequest{
+		InstanceID:        o.instanceID,
+		WorkflowComponent: workflowComponent,
+		WorkflowName:      workflowName,
+		Options:           o.options,
+		Input:             data,
+		SendRawInput:      true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &WorkflowHandle{
+		InstanceID:   resp.InstanceID,
+		client:       c,
+		component:    workflowComponent,
+		pollInterval: o.pollInterval,
+	}, nil
+}
+
+// WaitForCompletion polls the status of the workflow until it completed, failed, was terminated or was
+// canceled, and returns its last status. A failed workflow isn't an error, its RuntimeStatus must be checked by the caller.
+func (h *WorkflowHandle) WaitForCompletion(ctx context.Context) (*GetWorkflowResponse, error) {
+	if h.pollInterval <= 0 {
+		return nil, errors.New("workflow handle not created by StartWorkflowAs")
+	}
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+	for {
+		resp, err := h.client.GetWorkflowBeta1(ctx, &GetWorkflowRequest{
+			InstanceID:        h.InstanceID,
+			WorkflowComponent: h.component,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(workflowTerminalStatuses, strings.ToUpper(resp.RuntimeStatus)) {
+			return resp, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error waiting for workflow %s to complete: %w", h.InstanceID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Terminate stops the workflow.
+func (h *WorkflowHandle) Terminate(ctx context.Context) error {
+	return h.client.TerminateWorkflowBeta1(ctx, &TerminateWorkflowRequest{
+		InstanceID:        h.InstanceID,
+		WorkflowComponent: h.component,
+	})
+}
+
+// RaiseEvent raises the eventName event on the workflow, with data encoded as JSON when not nil.
+func (h *WorkflowHandle) RaiseEvent(ctx context.Context, eventName string, data any) error {
+	return h.client.RaiseEventWorkflowBeta1(ctx, &RaiseEventWorkflowRequest{
+		InstanceID:        h.InstanceID,
+		WorkflowComponent: h.component,
+		EventName:         eventName,
+		EventData:         data,
+	})
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderInput struct {
+	OrderID string `json:"orderId"`
+	Amount  int    `json:"amount"`
+}
+
+// workflowRecordingClient records the workflow requests and reports the statuses in order.
+type workflowRecordingClient struct {
+	Client
+	started    *StartWorkflowRequest
+	gets       int
+	statuses   []string
+	terminated *TerminateWorkflowRequest
+	raised     *RaiseEventWorkflowRequest
+}
+
+func (c *workflowRecordingClient) StartWorkflowBeta1(ctx context.Context, req *StartWorkflowRequest) (*StartWorkflowResponse, error) {
+	c.started = req
+	id := req.InstanceID
+	if id == "" {
+		id = "generated"
+	}
+	return &StartWorkflowResponse{InstanceID: id}, nil
+}
+
+func (c *workflowRecordingClient) GetWorkflowBeta1(ctx context.Context, req *GetWorkflowRequest) (*GetWorkflowResponse, error) {
+	status := c.statuses[min(c.gets, len(c.statuses)-1)]
+	c.gets++
+	return &GetWorkflowResponse{InstanceID: req.InstanceID, RuntimeStatus: status}, nil
+}
+
+func (c *workflowRecordingClient) TerminateWorkflowBeta1(ctx context.Context, req *TerminateWorkflowRequest) error {
+	c.terminated = req
+	return nil
+}
+
+func (c *workflowRecordingClient) RaiseEventWorkflowBeta1(ctx context.Context, req *RaiseEventWorkflowRequest) error {
+	c.raised = req
+	return nil
+}
+
+// go test -timeout 30s ./client -count 1 -run ^TestStartWorkflowAs$
+func TestStartWorkflowAs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("input is encoded as JSON", func(t *testing.T) {
+		fake := &workflowRecordingClient{}
+		h, err := StartWorkflowAs(ctx, fake, "", "OrderWorkflow", orderInput{OrderID: "1", Amount: 3},
+			WithWorkflowInstanceID("order-1"), WithWorkflowOptions(map[string]string{"k": "v"}))
+		require.NoError(t, err)
+		assert.Equal(t, "order-1", h.InstanceID)
+		assert.Equal(t, DefaultWorkflowComponent, fake.started.WorkflowComponent)
+		assert.Equal(t, "OrderWorkflow", fake.started.WorkflowName)
+		assert.Equal(t, map[string]string{"k": "v"}, fake.started.Options)
+		assert.True(t, fake.started.SendRawInput)
+		assert.JSONEq(t, `{"orderId":"1","amount":3}`, string(fake.started.Input.([]byte)))
+	})
+
+	t.Run("handle methods target the instance", func(t *testing.T) {
+		fake := &workflowRecordingClient{statuses: []string{"RUNNING", "RUNNING", "COMPLETED"}}
+		h, err := StartWorkflowAs(ctx, fake, "engine", "OrderWorkflow", 42, WithWorkflowPollInterval(time.Millisecond))
+		require.NoError(t, err)
+		assert.Equal(t, "generated", h.InstanceID)
+
+		require.NoError(t, h.RaiseEvent(ctx, "approved", true))
+		assert.Equal(t, &RaiseEventWorkflowRequest{InstanceID: "generated", WorkflowComponent: "engine", EventName: "approved", EventData: true}, fake.raised)
+
+		resp, err := h.WaitForCompletion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "COMPLETED", resp.RuntimeStatus)
+		assert.Equal(t, 3, fake.gets)
+
+		require.NoError(t, h.Terminate(ctx))
+		assert.Equal(t, &TerminateWorkflowRequest{InstanceID: "generated", WorkflowComponent: "engine"}, fake.terminated)
+	})
+
+	t.Run("wait stops on every terminal status", func(t *testing.T) {
+		for _, status := range []string{"COMPLETED", "FAILED", "TERMINATED", "CANCELED"} {
+			fake := &workflowRecordingClient{statuses: []string{"RUNNING", status}}
+			h, err := StartWorkflowAs(ctx, fake, "", "OrderWorkflow", 1, WithWorkflowPollInterval(time.Millisecond))
+			require.NoError(t, err)
+			resp, err := h.WaitForCompletion(ctx)
+			require.NoError(t, err, status)
+			assert.Equal(t, status, resp.RuntimeStatus)
+			assert.Equal(t, 2, fake.gets, status)
+		}
+	})
+
+	t.Run("wait stops with the context", func(t *testing.T) {
+		fake := &workflowRecordingClient{statuses: []string{"RUNNING"}}
+		h, err := StartWorkflowAs(ctx, fake, "", "OrderWorkflow", orderInput{}, WithWorkflowPollInterval(time.Millisecond))
+		require.NoError(t, err)
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = h.WaitForCompletion(waitCtx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("invalid poll interval", func(t *testing.T) {
+		_, err := StartWorkflowAs(ctx, &workflowRecordingClient{}, "", "OrderWorkflow", 1, WithWorkflowPollInterval(0))
+		require.Error(t, err)
+	})
+}
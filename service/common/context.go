@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	// TraceParentKey is the W3C trace context header propagated by Dapr.
+	TraceParentKey = "traceparent"
+	// TraceStateKey is the W3C trace state header propagated by Dapr.
+	TraceStateKey = "tracestate"
+)
+
+// HandlerInfo holds the trace context and metadata of the inbound request
+// which triggered a topic or binding handler.
+type HandlerInfo struct {
+	// TraceID is the trace ID parsed from the traceparent header, if any.
+	TraceID string
+	// SpanID is the parent span ID parsed from the traceparent header, if any.
+	SpanID string
+	// TraceParent is the raw traceparent header value.
+	TraceParent string
+	// TraceState is the raw tracestate header value.
+	TraceState string
+	// Metadata holds the inbound headers (HTTP) or metadata (gRPC) with lower-cased keys.
+	// When a key has multiple values only the first one is kept.
+	Metadata map[string]string
+}
+
+type handlerInfoKey struct{}
+
+// NewHandlerInfo builds a HandlerInfo from inbound headers or gRPC metadata.
+func NewHandlerInfo(headers map[string][]string) *HandlerInfo {
+	info := &HandlerInfo{
+		Metadata: make(map[string]string, len(headers)),
+	}
+	for k, v := range headers {
+		if len(v) == 0 {
+			continue
+		}
+		info.Metadata[strings.ToLower(k)] = v[0]
+	}
+	info.TraceParent = info.Metadata[TraceParentKey]
+	info.TraceState = info.Metadata[TraceStateKey]
+	info.TraceID, info.SpanID = parseTraceParent(info.TraceParent)
+	return info
+}
+
+// NewHandlerContext returns a copy of ctx carrying the given handler info.
+func NewHandlerContext(ctx context.Context, info *HandlerInfo) context.Context {
+	return context.WithValue(ctx, handlerInfoKey{}, info)
+}
+
+// FromHandlerContext returns the handler info attached to the context passed to
+// topic and binding handlers.
+func FromHandlerContext(ctx context.Context) (*HandlerInfo, bool) {
+	info, ok := ctx.Value(handlerInfoKey{}).(*HandlerInfo)
+	return info, ok && info != nil
+}
+
+// parseTraceParent extracts the trace and span IDs from a W3C traceparent
+// value in the version-traceid-spanid-flags format.
+func parseTraceParent(tp string) (traceID, spanID string) {
+	parts := strings.Split(tp, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", ""
+	}
+	return parts[1], parts[2]
+}
@@ -59,7 +59,7 @@ func (s *Server) OnBindingEvent(ctx context.Context, in *pb.BindingEventRequest)
 			Data:     in.GetData(),
 			Metadata: in.GetMetadata(),
 		}
-		data, err := fn(ctx, e)
+		data, err := fn(withHandlerInfo(ctx), e)
 		if err != nil {
 			return nil, fmt.Errorf("error executing %s binding: %w", in.GetName(), err)
 		}
@@ -142,7 +142,7 @@ func (s *Server) OnTopicEvent(ctx context.Context, in *runtimev1pb.TopicEventReq
 				in.GetPath(), in.GetPubsubName(), in.GetTopic(),
 			)
 		}
-		retry, err := h(ctx, e)
+		retry, err := h(withHandlerInfo(ctx), e)
 		if err == nil {
 			return &runtimev1pb.TopicEventResponse{Status: runtimev1pb.TopicEventResponse_SUCCESS}, nil
 		}
@@ -157,6 +157,12 @@ func (s *Server) OnTopicEvent(ctx context.Context, in *runtimev1pb.TopicEventReq
 	)
 }
 
+// withHandlerInfo attaches the trace context and metadata of the incoming request to ctx.
+func withHandlerInfo(ctx context.Context) context.Context {
+	meta, _ := metadata.FromIncomingContext(ctx)
+	return common.NewHandlerContext(ctx, common.NewHandlerInfo(meta))
+}
+
 func getCustomMetadataFromContext(ctx context.Context) map[string]string {
 	md := make(map[string]string)
 	meta, ok := metadata.FromIncomingContext(ctx)
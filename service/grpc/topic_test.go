@@ -163,6 +163,37 @@ func TestTopic(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("topic event with trace context", func(t *testing.T) {
+		sub3 := &common.Subscription{
+			PubsubName: "messages",
+			Topic:      "test3",
+		}
+		var info *common.HandlerInfo
+		err := server.AddTopicEventHandler(sub3, func(ctx context.Context, e *common.TopicEvent) (retry bool, err error) {
+			var ok bool
+			info, ok = common.FromHandlerContext(ctx)
+			assert.True(t, ok)
+			return false, nil
+		})
+		require.NoError(t, err)
+
+		in := &runtime.TopicEventRequest{
+			Id:         "a123",
+			Topic:      sub3.Topic,
+			PubsubName: sub3.PubsubName,
+		}
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.New(map[string]string{
+			"traceparent":    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"correlation-id": "c1",
+		}))
+		_, err = server.OnTopicEvent(ctx, in)
+		require.NoError(t, err)
+		require.NotNil(t, info)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", info.TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", info.SpanID)
+		assert.Equal(t, "c1", info.Metadata["correlation-id"])
+	})
+
 	stopTestServer(t, server)
 }
 
@@ -65,7 +65,7 @@ func (s *Server) AddBindingInvocationHandler(route string, fn common.BindingInvo
 				Data:     content,
 				Metadata: meta,
 			}
-			out, err := fn(r.Context(), in)
+			out, err := fn(withHandlerInfo(r), in)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
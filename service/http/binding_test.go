@@ -78,6 +78,31 @@ func TestBindingHandlerWithData(t *testing.T) {
 	assert.Equal(t, "test", resp.Body.String())
 }
 
+func TestBindingHandlerWithTraceContext(t *testing.T) {
+	s := newServer("", nil)
+	var info *common.HandlerInfo
+	err := s.AddBindingInvocationHandler("/", func(ctx context.Context, in *common.BindingEvent) (out []byte, err error) {
+		var ok bool
+		info, ok = common.FromHandlerContext(ctx)
+		assert.True(t, ok)
+		return nil, nil
+	})
+	require.NoErrorf(t, err, "error adding binding event handler")
+
+	req, err := http.NewRequest(http.MethodPost, "/", nil)
+	require.NoErrorf(t, err, "error creating request")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("X-Correlation-Id", "c1")
+
+	resp := httptest.NewRecorder()
+	s.mux.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	require.NotNil(t, info)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", info.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", info.SpanID)
+	assert.Equal(t, "c1", info.Metadata["x-correlation-id"])
+}
+
 func bindingHandlerFn(ctx context.Context, in *common.BindingEvent) (out []byte, err error) {
 	if in == nil {
 		return nil, errors.New("nil input")
@@ -14,6 +14,7 @@ limitations under the License.
 package http
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -300,7 +301,7 @@ func (s *Server) AddTopicEventHandler(sub *common.Subscription, fn common.TopicE
 			w.WriteHeader(http.StatusOK)
 
 			// execute user handler
-			retry, err := fn(r.Context(), &te)
+			retry, err := fn(withHandlerInfo(r), &te)
 			if err == nil {
 				writeStatus(w, common.SubscriptionResponseStatusSuccess)
 				return
@@ -317,6 +318,11 @@ func (s *Server) AddTopicEventHandler(sub *common.Subscription, fn common.TopicE
 	return nil
 }
 
+// withHandlerInfo attaches the trace context and headers of the request to its context.
+func withHandlerInfo(r *http.Request) context.Context {
+	return common.NewHandlerContext(r.Context(), common.NewHandlerInfo(r.Header))
+}
+
 func getCustomMetdataFromHeaders(r *http.Request) map[string]string {
 	md := make(map[string]string)
 	for k, v := range r.Header {